@@ -0,0 +1,82 @@
+package mule
+
+import "time"
+
+const (
+	// Key events further apart than this are separate presses rather
+	// than a key being held down
+	maxRepeatGap = 400 * time.Millisecond
+
+	// Longest time a held key is taken to still be down without
+	// hearing from the player
+	maxHold = 600 * time.Millisecond
+)
+
+// bidInput is the state of one player's auction key.  Keyboards and
+// remote players report a held key as a stream of repeated presses,
+// which may arrive unevenly over a network, so the key is taken to be
+// held for a while after each press.  How long depends on how far
+// apart the player's presses have been arriving.
+type bidInput struct {
+	dir     int
+	presses int
+	last    time.Time
+	gap     time.Duration
+}
+
+// press records a press of the up (dir 1) or down (dir -1) key at
+// time t.
+func (bi *bidInput) press(dir int, t time.Time) {
+
+	if dir == bi.dir && t.Sub(bi.last) < maxRepeatGap {
+		// The key is repeating, smooth the gap between presses
+		g := t.Sub(bi.last)
+		if bi.gap == 0 {
+			bi.gap = g
+		} else {
+			bi.gap = (bi.gap + g) / 2
+		}
+	} else {
+		bi.gap = 0
+	}
+
+	bi.dir = dir
+	bi.presses++
+	bi.last = t
+}
+
+// release records that the key is no longer held.
+func (bi *bidInput) release() {
+	bi.dir = 0
+	bi.presses = 0
+	bi.gap = 0
+}
+
+// held returns true if the key is taken to still be down at time t.
+// A single press is never held.
+func (bi *bidInput) held(t time.Time) bool {
+	if bi.gap == 0 {
+		return false
+	}
+	tol := 2 * bi.gap
+	if tol > maxHold {
+		tol = maxHold
+	}
+	return t.Sub(bi.last) <= tol
+}
+
+// move returns the direction in which the player moves at time t, and
+// starts collecting presses for the next move.  A player that pressed
+// the key since the last move, or is still holding it, moves one step.
+func (bi *bidInput) move(t time.Time) int {
+
+	dir := 0
+	if bi.presses > 0 || bi.held(t) {
+		dir = bi.dir
+	} else {
+		bi.release()
+	}
+	bi.presses = 0
+
+	return dir
+}
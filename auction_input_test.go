@@ -0,0 +1,54 @@
+package mule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBidInput(t *testing.T) {
+
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ms := func(n int) time.Time { return t0.Add(time.Duration(n) * time.Millisecond) }
+
+	type step struct {
+		at   int // ms
+		dir  int // pressed, or 0 to move
+		want int // direction of the move
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"a single press moves once", []step{
+			{0, 1, 0}, {50, 0, 1}, {100, 0, 0},
+		}},
+		{"presses between moves move once", []step{
+			{0, -1, 0}, {10, -1, 0}, {50, 0, -1}, {100, 0, 0},
+		}},
+		{"a held key moves until twice the repeat gap has passed", []step{
+			{0, 1, 0}, {30, 1, 0}, {50, 0, 1}, {60, 1, 0}, {100, 0, 1}, {120, 0, 1}, {150, 0, 0},
+		}},
+		{"presses further apart than a repeat aren't held", []step{
+			{0, 1, 0}, {500, 1, 0}, {550, 0, 1}, {600, 0, 0},
+		}},
+		{"a held key expires after maxHold", []step{
+			{0, 1, 0}, {350, 1, 0}, {400, 0, 1}, {900, 0, 1}, {1000, 0, 0},
+		}},
+		{"turning around isn't held", []step{
+			{0, 1, 0}, {30, -1, 0}, {50, 0, -1}, {100, 0, 0},
+		}},
+	}
+
+	for _, tc := range tests {
+		var bi bidInput
+		for _, s := range tc.steps {
+			if s.dir != 0 {
+				bi.press(s.dir, ms(s.at))
+				continue
+			}
+			if got := bi.move(ms(s.at)); got != s.want {
+				t.Errorf("%s: moved %d at %dms, want %d", tc.name, got, s.at, s.want)
+			}
+		}
+	}
+}
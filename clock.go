@@ -0,0 +1,230 @@
+package mule
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for the game.  All of the game's timers
+// and pauses go through its Clock, so that the game can be run faster
+// than real time, or stepped by hand in tests.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	NewTimer(d time.Duration) Timer
+	AfterFunc(d time.Duration, f func()) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a time.Timer from a Clock.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Ticker is a time.Ticker from a Clock.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// RealClock is the wall clock.
+type RealClock struct{}
+
+type realTimer struct{ t *time.Timer }
+
+func (rt realTimer) C() <-chan time.Time { return rt.t.C }
+func (rt realTimer) Stop() bool          { return rt.t.Stop() }
+
+type realTicker struct{ t *time.Ticker }
+
+func (rt realTicker) C() <-chan time.Time { return rt.t.C }
+func (rt realTicker) Stop()               { rt.t.Stop() }
+
+func (RealClock) Now() time.Time                 { return time.Now() }
+func (RealClock) Sleep(d time.Duration)          { time.Sleep(d) }
+func (RealClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+func (RealClock) AfterFunc(d time.Duration, f func()) Timer {
+	return realTimer{time.AfterFunc(d, f)}
+}
+
+func (RealClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+// FakeClock only moves when it is advanced, firing the timers,
+// tickers and sleeps that come due on the way.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter is a timer, ticker or sleep waiting on a FakeClock.
+type fakeWaiter struct {
+	fc     *FakeClock
+	when   time.Time
+	period time.Duration // for tickers
+	c      chan time.Time
+	f      func()
+}
+
+// NewFakeClock returns a clock stopped at time start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+func (fc *FakeClock) Now() time.Time {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.now
+}
+
+func (fc *FakeClock) add(d, period time.Duration, f func()) *fakeWaiter {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	w := &fakeWaiter{fc: fc, when: fc.now.Add(d), period: period, f: f}
+	if f == nil {
+		w.c = make(chan time.Time, 1)
+	}
+	fc.waiters = append(fc.waiters, w)
+	return w
+}
+
+// Sleep blocks until the clock has been advanced by d.
+func (fc *FakeClock) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	w := fc.add(d, 0, nil)
+	<-w.c
+}
+
+func (fc *FakeClock) NewTimer(d time.Duration) Timer {
+	return fc.add(d, 0, nil)
+}
+
+func (fc *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	return fc.add(d, 0, f)
+}
+
+func (fc *FakeClock) NewTicker(d time.Duration) Ticker {
+	return fakeTicker{fc.add(d, d, nil)}
+}
+
+// Waiters returns the number of timers, tickers and sleeps waiting on
+// the clock, so that a test can tell when the game is blocked on it.
+func (fc *FakeClock) Waiters() int {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return len(fc.waiters)
+}
+
+// next returns the time at which the next timer, ticker or sleep goes
+// off, or false if nothing is waiting on the clock.
+func (fc *FakeClock) next() (time.Time, bool) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	var t time.Time
+	for _, w := range fc.waiters {
+		if t.IsZero() || w.when.Before(t) {
+			t = w.when
+		}
+	}
+	return t, !t.IsZero()
+}
+
+// Advance moves the clock forward by d, firing everything that comes
+// due in order.  Functions given to AfterFunc are run before Advance
+// returns, so that whatever they send has been sent.
+func (fc *FakeClock) Advance(d time.Duration) {
+
+	end := fc.Now().Add(d)
+	for fc.fireFirst(end) {
+	}
+	fc.set(end)
+}
+
+// fireNext moves the clock on to the next timer, ticker or sleep and
+// fires only that one, the first set if others are due at the same
+// time, as they would be a little later on the wall clock.  It returns
+// false if nothing is waiting on the clock.
+func (fc *FakeClock) fireNext() bool {
+	return fc.fireFirst(time.Time{})
+}
+
+// fireFirst fires the first timer, ticker or sleep that is due by end,
+// or the first of all if end is zero, and returns false if there is
+// none.  The clock moves on to its time, unless it is already past it.
+func (fc *FakeClock) fireFirst(end time.Time) bool {
+
+	fc.mu.Lock()
+	var next *fakeWaiter
+	for _, w := range fc.waiters {
+		if (end.IsZero() || !w.when.After(end)) && (next == nil || w.when.Before(next.when)) {
+			next = w
+		}
+	}
+	if next == nil {
+		fc.mu.Unlock()
+		return false
+	}
+
+	if next.when.After(fc.now) {
+		fc.now = next.when
+	}
+	now := fc.now
+	if next.period > 0 {
+		next.when = next.when.Add(next.period)
+	} else {
+		fc.remove(next)
+	}
+	fc.mu.Unlock()
+
+	// The lock is let go first, f may set timers
+	if next.f != nil {
+		next.f()
+		return true
+	}
+
+	// Like time.Timer, a tick is dropped if the last one hasn't been
+	// taken
+	select {
+	case next.c <- now:
+	default:
+	}
+	return true
+}
+
+// set moves the clock on to t without firing anything, e.g. for a
+// replay to give the game an input at the time it was taken.
+func (fc *FakeClock) set(t time.Time) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if t.After(fc.now) {
+		fc.now = t
+	}
+}
+
+// remove takes w off the clock, returning false if it wasn't on it.
+func (fc *FakeClock) remove(w *fakeWaiter) bool {
+	for k, x := range fc.waiters {
+		if x == w {
+			fc.waiters = append(fc.waiters[:k], fc.waiters[k+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (w *fakeWaiter) C() <-chan time.Time { return w.c }
+
+func (w *fakeWaiter) Stop() bool {
+	w.fc.mu.Lock()
+	defer w.fc.mu.Unlock()
+	return w.fc.remove(w)
+}
+
+type fakeTicker struct{ w *fakeWaiter }
+
+func (ft fakeTicker) C() <-chan time.Time { return ft.w.c }
+func (ft fakeTicker) Stop()               { ft.w.Stop() }
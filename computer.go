@@ -0,0 +1,448 @@
+package mule
+
+import (
+	"fmt"
+	"time"
+)
+
+// PlotChooser decides whether player p claims plot plt, which is
+// currently highlighted in the plot selection phase.  remaining is
+// the number of unowned plots, including this one, that are still to
+// be highlighted this round, so that a chooser can become less picky
+// as the selection proceeds.
+type PlotChooser interface {
+	ClaimPlot(md *Model, p int, plt *Plot, remaining int) bool
+}
+
+// TurnDriver plays the development part of player p's turn in round
+// r, in place of a human walking through the store and the field.
+type TurnDriver interface {
+	PlayTurn(mg *MULE, p, r int)
+}
+
+// Bidder plays a computer player's part in an auction.
+type Bidder interface {
+	// Declare decides whether player p buys or sells
+	Declare(av *AuctionView, p int) typeBuySell
+
+	// Bid returns the direction (-1, 0 or 1) in which player p
+	// moves in the current auction move
+	Bid(av *AuctionView, p int) int
+}
+
+const (
+	// How long each step of a computer player's turn is shown for
+	computerDelay = 1500 * time.Millisecond
+
+	// Seconds of the player's turn used by a visit to a store
+	// counter, installing a MULE and processing an assay
+	shopTime    = 2
+	installTime = 1
+	assayTime   = 2
+)
+
+// Computer is a computer-controlled player.
+type Computer struct {
+	// Crystite levels that have been assayed, by plot index
+	assays map[int]int
+}
+
+func NewComputer() *Computer {
+	c := new(Computer)
+	c.assays = make(map[int]int)
+	return c
+}
+
+// Nominal value of a unit of each good, used before the store has
+// set any prices
+var nominalValue = map[outfitType]int{outfitFood: 30, outfitEnergy: 25,
+	outfitSmithore: 50, outfitCrystite: 100}
+
+// unitValue returns the value to player p of one unit of the good
+// produced by a MULE of type otp.  Food and energy are worth more
+// while the player produces less than it needs, so that the player
+// ends up self-sufficient.
+func (c *Computer) unitValue(md *Model, p int, otp outfitType) float64 {
+
+	v := float64(nominalValue[otp])
+	switch otp {
+	case outfitFood:
+		if md.foodStorePrice > 0 {
+			v = float64(md.foodStorePrice)
+		}
+	case outfitEnergy:
+		if md.energyStorePrice > 0 {
+			v = float64(md.energyStorePrice)
+		}
+	case outfitSmithore:
+		if md.smithoreStorePrice > 0 {
+			v = float64(md.smithoreStorePrice)
+		}
+	case outfitCrystite:
+		if md.crystiteStorePrice > 0 {
+			v = float64(md.crystiteStorePrice)
+		}
+	}
+
+	if otp == outfitFood || otp == outfitEnergy {
+		need := foodNeed(md.mule.round)
+		if otp == outfitEnergy {
+			need = md.energyNeed(p, true)
+		}
+		prod := 0
+		for _, plt := range md.plots {
+			if plt.Owned && plt.Owner == p && plt.MuleStatus == otp {
+				prod += plt.baseProduction()
+			}
+		}
+		if prod < need {
+			v *= 2
+		}
+	}
+
+	return v
+}
+
+// crystiteLevel returns what the player knows about the crystite
+// level of a plot.
+func (c *Computer) crystiteLevel(md *Model, plt *Plot) int {
+	return c.assays[plt.Row*md.ncol+plt.Col]
+}
+
+// bestOutfit returns the most valuable MULE type for the plot, and
+// its value per round to player p.
+func (c *Computer) bestOutfit(md *Model, p int, plt *Plot) (outfitType, float64) {
+
+	var best outfitType = outfitNone
+	bestv := 0.0
+	for _, otp := range []outfitType{outfitFood, outfitEnergy, outfitSmithore, outfitCrystite} {
+		q := *plt
+		q.MuleStatus = otp
+		if otp == outfitCrystite {
+			q.Crystite = c.crystiteLevel(md, plt)
+		}
+		v := float64(q.baseProduction()) * c.unitValue(md, p, otp)
+		if v > bestv {
+			best = otp
+			bestv = v
+		}
+	}
+
+	return best, bestv
+}
+
+// plotValue estimates the value of an unowned plot to player p.
+func (c *Computer) plotValue(md *Model, p int, plt *Plot) float64 {
+
+	_, v := c.bestOutfit(md, p, plt)
+
+	// Prefer plots next to our own, they are quicker to reach
+	for _, q := range md.neighbors(plt) {
+		if q.Owned && q.Owner == p {
+			v *= 1.1
+		}
+	}
+
+	return v
+}
+
+// ClaimPlot claims plots that are nearly as good as the best unowned
+// plot, and takes whatever is left near the end of the selection.
+func (c *Computer) ClaimPlot(md *Model, p int, plt *Plot, remaining int) bool {
+
+	if remaining <= 2 {
+		return true
+	}
+
+	best := 0.0
+	for _, q := range md.plots {
+		if q.Owned || md.isStore(q.Row, q.Col) {
+			continue
+		}
+		if v := c.plotValue(md, p, q); v > best {
+			best = v
+		}
+	}
+
+	return c.plotValue(md, p, plt) >= 0.9*best
+}
+
+// walkTime returns the number of seconds needed to walk between the
+// store and a plot.
+func walkTime(md *Model, plt *Plot) int {
+	si, sj := md.storePlot()
+	d := plt.Row - si
+	if d < 0 {
+		d = -d
+	}
+	e := plt.Col - sj
+	if e < 0 {
+		e = -e
+	}
+	return d + e + plt.Mountains
+}
+
+// nextDevelopment returns the undeveloped plot owned by player p that
+// is worth the most, along with the type of MULE to install on it.
+func (c *Computer) nextDevelopment(md *Model, p int) (*Plot, outfitType) {
+
+	var best *Plot
+	var besto outfitType = outfitNone
+	bestv := 0.0
+	for _, plt := range md.plots {
+		if !plt.Owned || plt.Owner != p || plt.MuleStatus != outfitNone {
+			continue
+		}
+		otp, v := c.bestOutfit(md, p, plt)
+		if v > bestv {
+			best, besto, bestv = plt, otp, v
+		}
+	}
+
+	return best, besto
+}
+
+// assayCandidate returns an unowned plot that has not been assayed,
+// preferring plots next to known crystite and close to the store.
+func (c *Computer) assayCandidate(md *Model) *Plot {
+
+	var best *Plot
+	bestv := 0
+	for _, plt := range md.plots {
+		if plt.Owned || md.isStore(plt.Row, plt.Col) {
+			continue
+		}
+		if _, ok := c.assays[plt.Row*md.ncol+plt.Col]; ok {
+			continue
+		}
+		v := 0
+		for _, q := range md.neighbors(plt) {
+			v += 10 * c.assays[q.Row*md.ncol+q.Col]
+		}
+		v -= walkTime(md, plt)
+		if best == nil || v > bestv {
+			best, bestv = plt, v
+		}
+	}
+
+	return best
+}
+
+// PlayTurn buys, outfits and installs MULEs on the most valuable
+// undeveloped plots while there is time and money, then assays a
+// plot for future rounds and spends any remaining time in the pub.
+func (c *Computer) PlayTurn(mg *MULE, p, r int) {
+
+	md := mg.Model
+	py := md.Players[p]
+	left := py.availableTime
+	mg.timeRemaining = left
+
+	// step uses up t seconds of the turn and shows what was done
+	step := func(t int, msg string) {
+		left -= t
+		mg.timeRemaining = left
+		mg.Fieldview.PrintTime(fmt.Sprintf("Time: %2ds    ", left))
+		mg.updateStatusBar(p)
+		mg.Banner(mg.PlayerNames[p]+" "+msg, 0)
+		mg.Logger.Printf("Player %d (computer) %s", p, msg)
+		mg.sleep(computerDelay)
+	}
+
+	for {
+		plt, otp := c.nextDevelopment(md, p)
+		if plt == nil {
+			break
+		}
+		wt := walkTime(md, plt)
+		if 2*shopTime+2*wt+installTime > left {
+			break
+		}
+		if md.storeMules == 0 || md.muleStorePrice+md.outfitCost(otp) > py.money {
+			break
+		}
+
+		if py.BuyMule() != buyResultSuccess {
+			break
+		}
+		step(shopTime, "bought a MULE")
+
+		// Give the MULE back if it can't be outfitted
+		if py.Outfit(otp) != outfitResultSuccess {
+			py.BuyMule()
+			step(shopTime, "returned a MULE")
+			break
+		}
+		py.muleOutfitType = otp
+		py.muleSymbol = osym[otp]
+		step(shopTime, fmt.Sprintf("outfitted a MULE for %s", otype_names[otp]))
+
+		plt.MuleStatus = otp
+		mg.logEvent(EventMuleInstalled, p, "row", plt.Row, "col", plt.Col, "good", goodName(otp))
+		py.hasMule = false
+		py.muleOutfitType = outfitNone
+		mg.Fieldview.DrawOwnedPlots()
+		step(2*wt+installTime, fmt.Sprintf("installed a %s MULE", otype_names[otp]))
+	}
+
+	if plt := c.assayCandidate(md); plt != nil && md.level.crystite() {
+		t := assayTime + 2*walkTime(md, plt)
+		if t < left {
+			c.assays[plt.Row*md.ncol+plt.Col] = plt.Crystite
+			step(t, "had a soil sample assayed")
+		}
+	}
+
+	if left > 0 {
+		_, amt := py.gamblePub(r, mg)
+		mg.updateStatusBar(p)
+		msg := fmt.Sprintf("%s won $%d gambling!", mg.PlayerNames[p], amt)
+		mg.Banner(msg, 0)
+		mg.Logger.Printf("Player %d (computer) won $%d in the pub", p, amt)
+		mg.sleep(computerDelay)
+	}
+}
+
+// surplus returns the number of units of the good being auctioned
+// that player p has beyond what it needs for the next round.
+func (c *Computer) surplus(av *AuctionView, p int) int {
+	py := av.mule.Model.Players[p]
+	switch av.aucType {
+	case food:
+		return py.Food - py.requiredFood
+	case energy:
+		return py.Energy - py.requiredEnergy
+	case smithore:
+		return py.Smithore
+	case crystite:
+		return py.Crystite
+	}
+	return 0
+}
+
+// Declare sells goods in surplus, and buys goods that are short if a
+// unit can be had at the price expected, see buyPrice.  A player with
+// nothing to gain from buying declares as a seller if it has any units,
+// and then stays out of the auction.
+func (c *Computer) Declare(av *AuctionView, p int) typeBuySell {
+	s := c.surplus(av, p)
+	if av.canSell[p] && s > 0 {
+		return seller
+	}
+	if s < 0 {
+		if price, ok := c.buyPrice(av, p); ok && price <= av.mule.Model.Players[p].money {
+			return buyer
+		}
+	}
+	if av.canSell[p] {
+		return seller
+	}
+	return buyer
+}
+
+// buyPrice returns the price that player p expects to pay for a unit:
+// the store's price if the store has stock, or else the price at which
+// the store buys, below which no other player sells.  It returns false
+// if nobody is expected to sell.
+func (c *Computer) buyPrice(av *AuctionView, p int) (int, bool) {
+	mg := av.mule
+	if mg.Model.getStoreAmount(av.aucType) > 0 {
+		return av.maxprice, true
+	}
+	for q := 0; q < mg.nplayers; q++ {
+		if q != p && av.canSell[q] && c.surplus(av, q) > 0 {
+			return av.minPrice, true
+		}
+	}
+	return 0, false
+}
+
+// landTarget bids one step above the best other bid in a land
+// auction, up to what the plot would earn by the end of the game less
+// the cost of a MULE, and at most half of the player's money.
+func (c *Computer) landTarget(av *AuctionView, p int) int {
+
+	mg := av.mule
+	md := mg.Model
+
+	limit := c.plotValue(md, p, av.lot)*float64(md.level.rounds()-1-mg.round) - float64(md.muleStorePrice)
+	if m := float64(md.Players[p].money) / 2; m < limit {
+		limit = m
+	}
+
+	top := barmin - 1
+	for q := 0; q < mg.nplayers; q++ {
+		if q != p && av.buySell[q] == buyer && av.pos[q] > top {
+			top = av.pos[q]
+		}
+	}
+
+	target := top + 1
+	if target > barmax {
+		target = barmax
+	}
+	if float64(av.posMoney(target)) > limit {
+		return barmin - 1
+	}
+	return target
+}
+
+// Bid moves a seller toward the highest buyer, or down to the store
+// price if there are no buyers, until its surplus is gone.  A buyer
+// that is short of food or energy moves toward the lowest seller, or
+// up to the store price if there are no sellers and the store has
+// stock.  Computer players don't speculate in smithore or crystite.
+// In land auctions the bid follows landTarget.
+func (c *Computer) Bid(av *AuctionView, p int) int {
+
+	mg := av.mule
+	md := mg.Model
+
+	anyBuyers := false
+	anySellers := false
+	for q := 0; q < mg.nplayers; q++ {
+		if q == p {
+			continue
+		}
+		if av.buySell[q] == buyer && av.pos[q] >= barmin {
+			anyBuyers = true
+		}
+		if av.buySell[q] == seller && av.pos[q] <= barmax {
+			anySellers = true
+		}
+	}
+
+	var target int
+	if av.aucType == land {
+		target = c.landTarget(av, p)
+	} else if av.buySell[p] == seller {
+		switch {
+		case c.surplus(av, p) <= 0:
+			target = barmax + 1
+		case anyBuyers:
+			target = av.barposl
+		default:
+			target = barmin
+		}
+	} else {
+		need := -c.surplus(av, p)
+		switch {
+		case need <= 0 || av.aucType == smithore || av.aucType == crystite:
+			target = barmin - 1
+		case anySellers:
+			target = av.barposu
+		case md.getStoreAmount(av.aucType) > 0:
+			target = barmax
+		default:
+			target = barmin - 1
+		}
+	}
+
+	switch {
+	case target > av.pos[p]:
+		return 1
+	case target < av.pos[p]:
+		return -1
+	}
+	return 0
+}
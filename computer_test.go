@@ -0,0 +1,78 @@
+package mule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnitValueLeavesModel(t *testing.T) {
+
+	mg, _, _, _ := newTestGame()
+	md := mg.Model
+	for _, py := range md.Players {
+		py.requiredFood = 9
+		py.requiredEnergy = 9
+	}
+
+	c := NewComputer()
+	c.unitValue(md, 0, outfitFood)
+	c.unitValue(md, 0, outfitEnergy)
+
+	for p, py := range md.Players {
+		if py.requiredFood != 9 || py.requiredEnergy != 9 {
+			t.Errorf("player %d needs %d food and %d energy after valuing a plot", p, py.requiredFood, py.requiredEnergy)
+		}
+	}
+}
+
+func TestComputerTurnAfterTimeRanOut(t *testing.T) {
+
+	mg, fc, _, _ := newTestGame()
+	py := mg.Model.Players[1]
+
+	// The last turn ran out of time, the computer can't afford
+	// anything and goes straight to the pub with all of its own
+	mg.timeRemaining = 0
+	py.money = 0
+	c := NewComputer()
+	play(t, fc, 50*time.Millisecond, func() {
+		py.availableTime = mg.Model.playerTurnTime(1, 0)
+		c.PlayTurn(mg, 1, 0)
+	}, func() {})
+
+	if py.money == 0 {
+		t.Error("the computer won nothing in the pub")
+	}
+}
+
+func TestDeclare(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		food, money int
+		storeFood   int
+		want        typeBuySell
+	}{
+		{"surplus", 10, 1000, 8, seller},
+		{"short", 1, 1000, 8, buyer},
+		{"short and broke", 1, 0, 8, seller},
+		{"short and nobody sells", 1, 1000, 0, seller},
+		{"nothing to sell", 0, 0, 8, buyer},
+	}
+
+	for _, tc := range tests {
+		mg, _, _, _ := newTestGame()
+		md := mg.Model
+		md.storeFood = tc.storeFood
+		py := md.Players[0]
+		py.Food, py.money = tc.food, tc.money
+		md.Players[1].Food = 0
+
+		av := mg.Auctionview
+		av.Init(food, 0)
+		av.SetInitialDeclarationStatus()
+		if got := NewComputer().Declare(av, 0); got != tc.want {
+			t.Errorf("%s: declared %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
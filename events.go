@@ -0,0 +1,64 @@
+package mule
+
+import (
+	"encoding/json"
+)
+
+// Types of the entries in the event log
+const (
+	EventPlotClaimed   = "plot_claimed"
+	EventPlotSold      = "plot_sold"
+	EventMuleBought    = "mule_bought"
+	EventMuleReturned  = "mule_returned"
+	EventMuleOutfitted = "mule_outfitted"
+	EventMuleInstalled = "mule_installed"
+	EventMuleEscaped   = "mule_escaped"
+	EventPub           = "pub"
+	EventWumpus        = "wumpus"
+	EventPlayer        = "player_event"
+	EventRound         = "round_event"
+	EventTrade         = "trade"
+	EventProduction    = "production"
+	EventConsumption   = "consumption"
+)
+
+// logEvent writes an entry to the event log, if there is one.  Each
+// entry is a JSON object on its own line, with the round (counting
+// from 1), the event type, the player the event is about (left out if
+// p is -1) and the key/value pairs in kv.
+func (mg *MULE) logEvent(typ string, p int, kv ...interface{}) {
+
+	if mg.Events == nil {
+		return
+	}
+
+	ev := map[string]interface{}{"round": mg.round + 1, "type": typ}
+	if p >= 0 {
+		ev["player"] = p
+	}
+	for k := 0; k+1 < len(kv); k += 2 {
+		ev[kv[k].(string)] = kv[k+1]
+	}
+
+	b, err := json.Marshal(ev)
+	if err != nil {
+		mg.Logger.Printf("Unable to log %s event: %v", typ, err)
+		return
+	}
+	mg.Events.Write(append(b, '\n'))
+}
+
+// goodName returns the name of a good in the event log.
+func goodName(otp outfitType) string {
+	switch otp {
+	case outfitFood:
+		return "food"
+	case outfitEnergy:
+		return "energy"
+	case outfitSmithore:
+		return "smithore"
+	case outfitCrystite:
+		return "crystite"
+	}
+	return "none"
+}
@@ -0,0 +1,74 @@
+package mule
+
+// ActionType is what a player asks the game to do, independent of the
+// device (keyboard, network, computer player) that produced it.
+type ActionType int
+
+const (
+	ActionNone ActionType = iota
+
+	// Move the player's piece one step in Action.Dir
+	ActionMove
+
+	// Continue past a message, install a MULE
+	ActionConfirm
+
+	// Take a soil sample for the assay office
+	ActionAssay
+
+	// Move up (toward selling) or down (toward buying) in an auction
+	ActionAuctionUp
+	ActionAuctionDown
+
+	// Claim the highlighted plot during plot selection
+	ActionClaimPlot
+
+	// Ask to end the current declaration or auction early, which
+	// ends once every human player has asked
+	ActionEndPhase
+
+	// Put the plot the player is standing on up for sale
+	ActionSellPlot
+
+	// Pause the game, or resume it if it is paused
+	ActionPause
+
+	// Start or stop trading privately in an auction
+	ActionCollude
+)
+
+type Direction int
+
+const (
+	DirUp Direction = iota
+	DirDown
+	DirLeft
+	DirRight
+)
+
+// AnyPlayer is the Player of an action that does not come from a
+// particular player, e.g. the arrow keys of a shared keyboard, which
+// move whoever's turn it is.
+const AnyPlayer = -1
+
+// Action is one input event from a player.
+type Action struct {
+	Player int
+	Type   ActionType
+	Dir    Direction
+}
+
+// from returns true if the action may be taken as coming from player
+// p.
+func (a Action) from(p int) bool {
+	return a.Player == AnyPlayer || a.Player == p
+}
+
+// player returns the player that the action belongs to, or -1 if it
+// does not belong to a valid player of this game.
+func (a Action) player(nplayers int) int {
+	if a.Player < 0 || a.Player >= nplayers {
+		return -1
+	}
+	return a.Player
+}
@@ -0,0 +1,282 @@
+package mule
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// Price of a plot at the bottom of the land auction bar, and the
+	// price difference between bar positions
+	landMinPrice  = 200
+	landPriceStep = 20
+
+	// Percent chance that the colony offers an unclaimed plot in a
+	// round
+	landOfferChance = 25
+
+	landAuctionTime = 10 * time.Second
+)
+
+// LandAuction sells the plots that players have put up for sale, and
+// sometimes an unclaimed plot offered by the colony, each to the
+// highest bidder.
+func (mg *MULE) LandAuction(r int) {
+
+	md := mg.Model
+
+	var lots []*Plot
+	for _, plt := range md.plots {
+		if plt.ForSale {
+			lots = append(lots, plt)
+		}
+	}
+
+	if int(md.rng.Int63()%100) < landOfferChance {
+		var free []*Plot
+		for _, plt := range md.plots {
+			if !plt.Owned && !md.isStore(plt.Row, plt.Col) {
+				free = append(free, plt)
+			}
+		}
+		if len(free) > 0 {
+			lots = append(lots, free[int(md.rng.Int63()%int64(len(free)))])
+		}
+	}
+
+	for _, plt := range lots {
+		seller := -1
+		if plt.Owned {
+			seller = plt.Owner
+		}
+		plt.ForSale = false
+		mg.Auctionview.InitLand(plt, seller)
+		mg.Auctionview.DoLandAuction()
+	}
+}
+
+// InitLand sets up an auction of plot plt by player seller, or by the
+// colony if seller is -1.
+func (av *AuctionView) InitLand(plt *Plot, seller int) {
+	// Land has no store price, so the store's prices and the random
+	// numbers they use are left alone
+	av.initState(land)
+	av.lot = plt
+	av.lotSeller = seller
+	av.minPrice = landMinPrice
+	av.maxprice = landMinPrice + landPriceStep*(barmax-barmin)
+}
+
+// DoLandAuction shows the plot for sale, runs the auction and hands
+// the plot over to the winner.
+func (av *AuctionView) DoLandAuction() {
+
+	mg := av.mule
+	md := mg.Model
+	fv := mg.Fieldview
+	plt := av.lot
+
+	// Show the plot on the field first
+	fv.Clear()
+	fv.DrawLandscape()
+	fv.DrawOwnedPlots()
+	fv.HighlightPlot(plt.Row, plt.Col, 'X', ColorCyan, false)
+	var msg string
+	if av.lotSeller < 0 {
+		msg = "The colony is selling this plot, press space to start the land auction"
+	} else {
+		msg = fmt.Sprintf("%s is selling this plot, press space to start the land auction",
+			mg.PlayerNames[av.lotSeller])
+	}
+	mg.Banner(msg, 0)
+	mg.Banner(av.keyMsg(), 1)
+	mg.Renderer.Flush()
+	mg.WaitForSpace(AnyPlayer)
+
+	// Everyone but the seller is a buyer
+	for p := 0; p < mg.nplayers; p++ {
+		if p == av.lotSeller {
+			av.buySell[p] = seller
+			av.pos[p] = barmax + 1
+		} else {
+			av.buySell[p] = buyer
+			av.pos[p] = barmin - 1
+		}
+	}
+
+	av.Clear()
+	av.Render()
+	av.drawLimitsAuction()
+	av.drawLabels()
+	av.drawPlayers()
+	mg.Banner("Land auction... (press backspace to end)", 0)
+	mg.Renderer.Flush()
+
+	winner, price := av.RunLandAuction()
+	if winner < 0 {
+		mg.Logger.Printf("Nobody bid for plot %d,%d", plt.Row, plt.Col)
+		mg.Banner("Nobody bid for the plot", 0)
+		mg.sleep(2 * time.Second)
+		return
+	}
+
+	md.Players[winner].money -= price
+	if av.lotSeller >= 0 {
+		md.Players[av.lotSeller].money += price
+	}
+	plt.Owned = true
+	plt.Owner = winner
+
+	mg.Logger.Printf("Player %d bought plot %d,%d for $%d", winner, plt.Row, plt.Col, price)
+	mg.logEvent(EventPlotSold, winner, "row", plt.Row, "col", plt.Col, "seller", av.lotSeller, "price", price)
+	mg.Banner(fmt.Sprintf("%s bought the plot for $%d", mg.PlayerNames[winner], price), 0)
+	av.printPlayerAmounts()
+	mg.Renderer.Flush()
+	mg.sleep(2 * time.Second)
+}
+
+// RunLandAuction lets the buyers bid the price up until time runs out
+// or the auction is ended, and returns the winning player and price.
+// The winner is -1 if nobody bid.
+func (av *AuctionView) RunLandAuction() (int, int) {
+
+	mg := av.mule
+	mg.startPhase()
+
+	timer := mg.Clock.NewTimer(landAuctionTime)
+	defer timer.Stop()
+	tick := mg.Clock.NewTicker(eventDelay)
+	defer tick.Stop()
+
+	// Tick at which each buyer reached its bid, the first to reach
+	// the top bid wins a tie
+	since := make([]int, mg.nplayers)
+	end := mg.newVotes()
+
+	var now time.Time
+	for cnt := 0; ; {
+
+		select {
+		case now = <-tick.C():
+			mg.woke(tick.C())
+			cnt++
+
+		case <-timer.C():
+			mg.woke(timer.C())
+			mg.Banner("The land auction is over!", 0)
+			return av.landWinner(since)
+
+		case a := <-mg.input(tick.C(), timer.C()):
+			mg.took(a)
+			p := av.humanPlayer(a)
+			switch {
+			case a.Type == ActionEndPhase:
+				if end.add(a) {
+					mg.Banner("Land auction ended early!", 0)
+					mg.Renderer.Flush()
+					mg.sleep(1 * time.Second)
+					return av.landWinner(since)
+				}
+			case p >= 0 && p == av.lotSeller:
+				// The seller can't bid
+			case a.Type == ActionAuctionUp && p >= 0:
+				av.input[p].press(1, mg.Clock.Now())
+			case a.Type == ActionAuctionDown && p >= 0:
+				av.input[p].press(-1, mg.Clock.Now())
+			}
+			continue
+		}
+
+		copy(av.newpos, av.pos)
+		pushed := false
+		for p := 0; p < mg.nplayers; p++ {
+			if p == av.lotSeller {
+				continue
+			}
+			if b := mg.Bidders[p]; b != nil {
+				av.newpos[p] = av.pos[p] + b.Bid(av, p)
+			} else {
+				av.newpos[p] = av.pos[p] + av.input[p].move(now)
+			}
+
+			// A bidder pressing up at the top raises the price
+			if av.pos[p] == barmax && av.newpos[p] > barmax {
+				pushed = true
+			}
+		}
+
+		av.clipPos(av.newpos)
+
+		// The top bid sets the bar, two bidders both at the top also
+		// raise the price
+		barposl := barmin
+		atTop := 0
+		for p := 0; p < mg.nplayers; p++ {
+			if av.buySell[p] != buyer {
+				continue
+			}
+			if av.newpos[p] > barmax {
+				av.newpos[p] = barmax
+			}
+			if av.newpos[p] == barmax {
+				atTop++
+			}
+			if av.newpos[p] > barposl {
+				barposl = av.newpos[p]
+			}
+			if av.newpos[p] != av.pos[p] {
+				since[p] = cnt
+			}
+		}
+
+		av.redrawBars(barposl, barmax)
+		copy(av.pos, av.newpos)
+		av.drawPlayers()
+
+		// Bidding can go past the top of the bar, as long as one of
+		// the bidders there can pay
+		if (pushed || atTop > 1) && av.canPayAtTop(av.maxprice+1) {
+			av.maxprice++
+			av.printLimitPrices()
+		}
+
+		mg.Renderer.Flush()
+	}
+}
+
+// canPayAtTop returns true if a bidder at the top of the bar can pay
+// price.
+func (av *AuctionView) canPayAtTop(price int) bool {
+	md := av.mule.Model
+	for p := range av.pos {
+		if av.buySell[p] == buyer && av.pos[p] == barmax && md.Players[p].money >= price {
+			return true
+		}
+	}
+	return false
+}
+
+// landWinner returns the highest bidder that can pay, and the price.
+func (av *AuctionView) landWinner(since []int) (int, int) {
+
+	md := av.mule.Model
+
+	best := -1
+	for p := range av.pos {
+		if av.buySell[p] != buyer || av.pos[p] < barmin {
+			continue
+		}
+		if av.posMoney(av.pos[p]) > md.Players[p].money {
+			continue
+		}
+		if best < 0 || av.pos[p] > av.pos[best] ||
+			av.pos[p] == av.pos[best] && since[p] < since[best] {
+			best = p
+		}
+	}
+
+	if best < 0 {
+		return -1, 0
+	}
+	return best, av.posMoney(av.pos[best])
+}
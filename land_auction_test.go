@@ -0,0 +1,50 @@
+package mule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLandAuctionPrice(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		pos    []int
+		push   bool
+		raised bool
+		winner int
+	}{
+		{"alone at the top", []int{barmax, barmin - 1}, false, false, 0},
+		{"pushing at the top", []int{barmax, barmin - 1}, true, true, 0},
+		{"the other at the top", []int{barmin, barmax}, false, false, 1},
+		{"both at the top", []int{barmax, barmax}, false, true, 0},
+	}
+
+	for _, tc := range tests {
+		mg, fc, _, q := newTestGame()
+		av := mg.Auctionview
+		av.InitLand(mg.Model.GetPlot(0, 0), -1)
+		for p := range tc.pos {
+			av.buySell[p] = buyer
+			av.pos[p] = tc.pos[p]
+		}
+		start := av.maxprice
+
+		winner, price := -1, 0
+		play(t, fc, 50*time.Millisecond, func() { winner, price = av.RunLandAuction() }, func() {
+			if tc.push {
+				press(q, Action{Player: 0, Type: ActionAuctionUp})
+			}
+		})
+
+		if winner != tc.winner {
+			t.Errorf("%s: player %d won, want %d", tc.name, winner, tc.winner)
+		}
+		if raised := av.maxprice > start; raised != tc.raised {
+			t.Errorf("%s: the top price went from $%d to $%d", tc.name, start, av.maxprice)
+		}
+		if winner >= 0 && price > mg.Model.Players[winner].money {
+			t.Errorf("%s: the winner can't pay $%d", tc.name, price)
+		}
+	}
+}
@@ -2,9 +2,8 @@ package mule
 
 import (
 	"fmt"
+	"sort"
 	"strings"
-
-	"github.com/nsf/termbox-go"
 )
 
 func (mg *MULE) getShortageWarnings() []string {
@@ -42,25 +41,25 @@ func (mg *MULE) getShortageWarnings() []string {
 
 func (mg *MULE) DoLeaderboard() {
 
-	fg := termbox.ColorWhite
-	bg := termbox.ColorBlack
+	fg := ColorWhite
+	bg := ColorBlack
 
 	for p := 0; p < mg.nplayers; p++ {
 		mg.Model.Players[p].updateScore(mg)
-		mg.Model.updatePlayerRanks()
 	}
+	mg.Model.updatePlayerRanks()
 
 	// Clear the screen
 	for y := 0; y < 40; y++ {
 		for x := 0; x < 80; x++ {
-			termbox.SetCell(x, y, ' ', bg, bg)
+			mg.Renderer.SetCell(x, y, ' ', bg, bg)
 		}
 	}
 
 	for q := 0; q < mg.nplayers; q++ {
 
 		// Display the players in rank order
-		var col termbox.Attribute
+		var col Attribute
 		var py *Player
 		var p int
 		for p = 0; p < mg.nplayers; p++ {
@@ -71,19 +70,22 @@ func (mg *MULE) DoLeaderboard() {
 			}
 		}
 
-		m := 3 + 7*p
-		mg.Print(2, m, mg.PlayerNames[p], col, bg)
-		mg.Print(18, m, fmt.Sprintf("%5d", py.score), col, bg)
-		mg.Print(2, m+1, "  Money", col, bg)
-		mg.Print(18, m+1, fmt.Sprintf("%5d", py.money), col, bg)
-		mg.Print(2, m+2, "  Food", col, bg)
-		mg.Print(18, m+2, fmt.Sprintf("%5d", py.Food), col, bg)
-		mg.Print(2, m+3, "  Energy", col, bg)
-		mg.Print(18, m+3, fmt.Sprintf("%5d", py.Energy), col, bg)
-		mg.Print(2, m+4, "  Smithore", col, bg)
-		mg.Print(18, m+4, fmt.Sprintf("%5d", py.Smithore), col, bg)
-		mg.Print(2, m+5, "  Crystite", col, bg)
-		mg.Print(18, m+5, fmt.Sprintf("%5d", py.Crystite), col, bg)
+		// Players past the fourth go in a second column
+		m := 3 + 7*(q%4)
+		x := 2 + 40*(q/4)
+		mg.Print(x, m, mg.PlayerNames[p], col, bg)
+		mg.Print(x+16, m, fmt.Sprintf("%5d", py.score), col, bg)
+		mg.Print(x+23, m, py.species.String(), col, bg)
+		mg.Print(x, m+1, "  Money", col, bg)
+		mg.Print(x+16, m+1, fmt.Sprintf("%5d", py.money), col, bg)
+		mg.Print(x, m+2, "  Food", col, bg)
+		mg.Print(x+16, m+2, fmt.Sprintf("%5d", py.Food), col, bg)
+		mg.Print(x, m+3, "  Energy", col, bg)
+		mg.Print(x+16, m+3, fmt.Sprintf("%5d", py.Energy), col, bg)
+		mg.Print(x, m+4, "  Smithore", col, bg)
+		mg.Print(x+16, m+4, fmt.Sprintf("%5d", py.Smithore), col, bg)
+		mg.Print(x, m+5, "  Crystite", col, bg)
+		mg.Print(x+16, m+5, fmt.Sprintf("%5d", py.Crystite), col, bg)
 	}
 
 	msgs := mg.getShortageWarnings()
@@ -93,12 +95,94 @@ func (mg *MULE) DoLeaderboard() {
 
 	mg.Print(1, 32, "Press space to continue", fg, bg)
 
-	mg.WaitForSpace()
+	mg.WaitForSpace(AnyPlayer)
+
+	// Clear the screen
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 80; x++ {
+			mg.Renderer.SetCell(x, y, ' ', bg, bg)
+		}
+	}
+}
+
+// The colony fails if it starves in this many rounds
+const starvedRoundsFail = 3
+
+// Ratings of the colony by its total score, from the original game
+var colonyRatings = []struct {
+	score int
+	msg   string
+}{
+	{0, "You will be tolerated."},
+	{20000, "You will be remembered."},
+	{40000, "You will be honored."},
+	{60000, "You will be celebrated as heroes."},
+	{80000, "You will be known as legends."},
+	{100000, "You will be hailed as the first founders!"},
+}
+
+// colonyRating returns the rating of a colony with the given total
+// score.
+func colonyRating(total int) string {
+	msg := colonyRatings[0].msg
+	for _, cr := range colonyRatings {
+		if total >= cr.score {
+			msg = cr.msg
+		}
+	}
+	return msg
+}
+
+// DoFinalSummary shows the colony's total score and rating, or its
+// failure, and the winner, after the last round.
+func (mg *MULE) DoFinalSummary() {
+
+	md := mg.Model
+	fg := ColorWhite
+	bg := ColorBlack
 
 	// Clear the screen
 	for y := 0; y < 40; y++ {
 		for x := 0; x < 80; x++ {
-			termbox.SetCell(x, y, ' ', bg, bg)
+			mg.Renderer.SetCell(x, y, ' ', bg, bg)
 		}
 	}
+
+	// Highest score first, the earlier player wins a tie
+	order := make([]int, mg.nplayers)
+	total := 0
+	for p, py := range md.Players {
+		total += py.score
+		order[p] = p
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return md.Players[order[i]].score > md.Players[order[j]].score
+	})
+	winner := order[0]
+
+	mg.Print(2, 1, "The ship has returned", fg, bg)
+	for k, p := range order {
+		py := md.Players[p]
+		m := 3 + k
+		mg.Print(2, m, mg.PlayerNames[p], mg.PlayerColors[p], bg)
+		mg.Print(18, m, fmt.Sprintf("%7d", py.score), mg.PlayerColors[p], bg)
+	}
+	m := 4 + mg.nplayers
+	mg.Print(2, m, "Colony", fg, bg)
+	mg.Print(18, m, fmt.Sprintf("%7d", total), fg, bg)
+
+	var rating string
+	if md.starvedRounds >= starvedRoundsFail {
+		rating = fmt.Sprintf("The colony has failed, it starved in %d rounds.", md.starvedRounds)
+	} else {
+		rating = colonyRating(total)
+	}
+	mg.Print(2, m+2, rating, fg, bg)
+	msg := fmt.Sprintf("%s is the winner!", mg.PlayerNames[winner])
+	mg.Print(2, m+3, msg, mg.PlayerColors[winner], bg)
+	mg.Logger.Printf("Colony total %d, starved %d rounds: %s", total, md.starvedRounds, rating)
+	mg.Logger.Printf("Player %d won with %d", winner, md.Players[winner].score)
+
+	mg.Print(1, 32, "Press space to end the game", fg, bg)
+	mg.WaitForSpace(AnyPlayer)
 }
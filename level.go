@@ -0,0 +1,85 @@
+package mule
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Level selects the rules of the game.  The zero value is the
+// standard game.
+type Level int
+
+const (
+	LevelStandard Level = iota
+
+	// A short game without crystite, with free outfitting
+	LevelBeginner
+
+	// Collusion in the auctions, bigger pirate raids and more
+	// variable store prices
+	LevelTournament
+)
+
+var levelNames = map[Level]string{LevelBeginner: "beginner",
+	LevelStandard: "standard", LevelTournament: "tournament"}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel returns the level with the given name.
+func ParseLevel(s string) (Level, error) {
+	for l, na := range levelNames {
+		if strings.EqualFold(s, na) {
+			return l, nil
+		}
+	}
+	return LevelStandard, fmt.Errorf("unknown level %q", s)
+}
+
+// rounds returns the number of rounds in a game.
+func (l Level) rounds() int {
+	if l == LevelBeginner {
+		return 6
+	}
+	return 12
+}
+
+// crystite returns true if there is crystite in the field, to be
+// found by assay and mined.
+func (l Level) crystite() bool {
+	return l != LevelBeginner
+}
+
+// freeOutfit returns true if MULEs come ready to be outfitted for any
+// good at no charge.
+func (l Level) freeOutfit() bool {
+	return l == LevelBeginner
+}
+
+// preOutfitted returns true if MULEs come from the store ready to
+// work, outfitting themselves for the plot they are installed on.
+func (l Level) preOutfitted() bool {
+	return l == LevelBeginner
+}
+
+// collusion returns true if a buyer and a seller may trade privately
+// in the auctions.
+func (l Level) collusion() bool {
+	return l == LevelTournament
+}
+
+// pirateLosses returns true if pirates also take the store's crystite
+// and half of everyone's smithore.
+func (l Level) pirateLosses() bool {
+	return l == LevelTournament
+}
+
+// priceVariance returns the percentage by which the store prices vary
+// randomly from round to round.
+func (l Level) priceVariance() int {
+	if l == LevelTournament {
+		return 20
+	}
+	return 0
+}
@@ -0,0 +1,194 @@
+package mule
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// The smallest field that the store and auction screens fit alongside
+const (
+	minRows = 3
+	minCols = 5
+)
+
+// The largest random field, which fills the screen with the smallest
+// plots
+const (
+	maxRows = fieldHeight / minPlotHeight
+	maxCols = fieldWidth / minPlotWidth
+)
+
+// Map is the layout of the field.  In a map file, each line is a row
+// of plots, with one word per plot, e.g.
+//
+//	# A small river valley
+//	.0 .1 22 .3 R2 .1 .0 10 .0
+//
+// The first character of a word is the terrain: '.' for plain land,
+// 'R' for the river, '1' to '3' for mountains of that height and 'S'
+// for the store, which must appear once.  The second character is the
+// crystite level, 0 to 4.  Blank lines and lines starting with '#'
+// are ignored.
+type Map struct {
+	Rows int
+	Cols int
+
+	StoreRow int
+	StoreCol int
+
+	// The plots, stored row-wise, with only the terrain set
+	Plots []*Plot
+}
+
+// LoadMap reads a map file.
+func LoadMap(fname string) (*Map, error) {
+
+	fid, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer fid.Close()
+
+	mp, err := ParseMap(fid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+
+	return mp, nil
+}
+
+// ParseMap reads a map in the map file format.
+func ParseMap(r io.Reader) (*Map, error) {
+
+	mp := new(Map)
+	mp.StoreRow = -1
+
+	scanner := bufio.NewScanner(r)
+	for ln := 1; scanner.Scan(); ln++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		words := strings.Fields(line)
+		if mp.Rows == 0 {
+			mp.Cols = len(words)
+		} else if len(words) != mp.Cols {
+			return nil, fmt.Errorf("line %d: expected %d plots, found %d", ln, mp.Cols, len(words))
+		}
+
+		for j, w := range words {
+			if len(w) != 2 || w[1] < '0' || w[1] > '4' {
+				return nil, fmt.Errorf("line %d: invalid plot %q", ln, w)
+			}
+			plt := &Plot{Row: mp.Rows, Col: j, MuleStatus: outfitNone, Crystite: int(w[1] - '0')}
+			switch c := w[0]; {
+			case c == '.':
+			case c == 'R':
+				plt.River = true
+			case c >= '1' && c <= '3':
+				plt.Mountains = int(c - '0')
+			case c == 'S':
+				if mp.StoreRow >= 0 {
+					return nil, fmt.Errorf("line %d: more than one store", ln)
+				}
+				mp.StoreRow, mp.StoreCol = mp.Rows, j
+			default:
+				return nil, fmt.Errorf("line %d: invalid terrain in %q", ln, w)
+			}
+			mp.Plots = append(mp.Plots, plt)
+		}
+		mp.Rows++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if mp.StoreRow < 0 {
+		return nil, fmt.Errorf("the map has no store")
+	}
+	if mp.Rows < minRows || mp.Cols < minCols {
+		return nil, fmt.Errorf("maps must be at least %dx%d", minRows, minCols)
+	}
+
+	// Players leave the store to the left and right
+	if mp.StoreCol == 0 || mp.StoreCol == mp.Cols-1 {
+		return nil, fmt.Errorf("the store can't be at the edge of the field")
+	}
+
+	return mp, nil
+}
+
+// ParseSize reads the size of a random field given as rows by columns,
+// e.g. "5x9".
+func ParseSize(s string) (int, int, error) {
+
+	var nrow, ncol int
+	if n, err := fmt.Sscanf(s, "%dx%d", &nrow, &ncol); n != 2 || err != nil {
+		return 0, 0, fmt.Errorf("invalid field size %q", s)
+	}
+	if nrow < minRows || ncol < minCols || nrow > maxRows || ncol > maxCols {
+		return 0, 0, fmt.Errorf("random fields must be from %dx%d to %dx%d", minRows, minCols, maxRows, maxCols)
+	}
+
+	return nrow, ncol, nil
+}
+
+// String returns the map in the map file format.
+func (mp *Map) String() string {
+
+	var b strings.Builder
+	for i := 0; i < mp.Rows; i++ {
+		for j := 0; j < mp.Cols; j++ {
+			plt := mp.Plots[i*mp.Cols+j]
+			c := byte('.')
+			switch {
+			case i == mp.StoreRow && j == mp.StoreCol:
+				c = 'S'
+			case plt.River:
+				c = 'R'
+			case plt.Mountains > 0:
+				c = byte('0' + plt.Mountains)
+			}
+			if j > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteByte(c)
+			b.WriteByte(byte('0' + plt.Crystite))
+		}
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+// Map returns the layout of the field.
+func (md *Model) Map() *Map {
+	mp := new(Map)
+	mp.Rows = md.nrow
+	mp.Cols = md.ncol
+	mp.StoreRow, mp.StoreCol = md.storePlot()
+	for _, plt := range md.plots {
+		mp.Plots = append(mp.Plots, &Plot{Row: plt.Row, Col: plt.Col, MuleStatus: outfitNone,
+			River: plt.River, Mountains: plt.Mountains, Crystite: plt.Crystite})
+	}
+	return mp
+}
+
+// usePlots lays out the field from a map.
+func (md *Model) usePlots(mp *Map) {
+	md.nrow, md.ncol = mp.Rows, mp.Cols
+	md.storeRow, md.storeCol = mp.StoreRow, mp.StoreCol
+	md.plots = make([]*Plot, len(mp.Plots))
+	for k, plt := range mp.Plots {
+		q := *plt
+		q.MuleStatus = outfitNone
+		if !md.level.crystite() {
+			q.Crystite = 0
+		}
+		md.plots[k] = &q
+	}
+}
@@ -0,0 +1,129 @@
+package mule
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMap(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		text       string
+		err        string
+		rows, cols int
+	}{
+		{"smallest", ".0 .0 .0 .0 .0\n.0 .0 S0 .0 .0\n.0 .0 .0 .0 .0\n", "", 3, 5},
+		{"comments and blank lines", "# a map\n\n.0 .0 .0 .0 .0\n  # more\n.0 R1 S2 34 .0\n\n.0 .0 .0 .0 .0\n", "", 3, 5},
+		{"too few rows", ".0 .0 S0 .0 .0\n.0 .0 .0 .0 .0\n", "at least 3x5", 0, 0},
+		{"too few columns", ".0 .0 .0 .0\n.0 S0 .0 .0\n.0 .0 .0 .0\n", "at least 3x5", 0, 0},
+		{"ragged", ".0 .0 .0 .0 .0\n.0 .0 S0 .0\n.0 .0 .0 .0 .0\n", "line 2: expected 5 plots, found 4", 0, 0},
+		{"no store", ".0 .0 .0 .0 .0\n.0 .0 .0 .0 .0\n.0 .0 .0 .0 .0\n", "no store", 0, 0},
+		{"two stores", ".0 .0 .0 .0 .0\n.0 S0 .0 S0 .0\n.0 .0 .0 .0 .0\n", "line 2: more than one store", 0, 0},
+		{"store on the left", ".0 .0 .0 .0 .0\nS0 .0 .0 .0 .0\n.0 .0 .0 .0 .0\n", "edge", 0, 0},
+		{"store on the right", ".0 .0 .0 .0 .0\n.0 .0 .0 .0 S0\n.0 .0 .0 .0 .0\n", "edge", 0, 0},
+		{"long token", ".0 .0 .0 .0 .0\n.0 .0 S00 .0 .0\n.0 .0 .0 .0 .0\n", `line 2: invalid plot "S00"`, 0, 0},
+		{"short token", ".0 .0 .0 .0 .0\n.0 . S0 .0 .0\n.0 .0 .0 .0 .0\n", `line 2: invalid plot "."`, 0, 0},
+		{"too much crystite", ".0 .0 .0 .0 .0\n.0 .5 S0 .0 .0\n.0 .0 .0 .0 .0\n", `line 2: invalid plot ".5"`, 0, 0},
+		{"bad terrain", ".0 .0 .0 .0 .0\n.0 .0 S0 .0 .0\n.0 .0 .0 40 .0\n", `line 3: invalid terrain in "40"`, 0, 0},
+	}
+
+	for _, tc := range tests {
+		mp, err := ParseMap(strings.NewReader(tc.text))
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%s: %v", tc.name, err)
+		case tc.err != "" && err == nil:
+			t.Errorf("%s: parsed a bad map", tc.name)
+		case tc.err != "" && !strings.Contains(err.Error(), tc.err):
+			t.Errorf("%s: got error %q, want %q", tc.name, err, tc.err)
+		case tc.err == "" && (mp.Rows != tc.rows || mp.Cols != tc.cols):
+			t.Errorf("%s: parsed a %dx%d map, want %dx%d", tc.name, mp.Rows, mp.Cols, tc.rows, tc.cols)
+		}
+	}
+}
+
+func TestMapString(t *testing.T) {
+
+	mp, err := LoadMap("maps/valley.map")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mp.StoreRow != 2 || mp.StoreCol != 4 {
+		t.Errorf("the store is at %d,%d", mp.StoreRow, mp.StoreCol)
+	}
+	plt := mp.Plots[1*mp.Cols+2]
+	if plt.Mountains != 3 || plt.Crystite != 1 {
+		t.Errorf("plot 1,2 has %d mountains and crystite %d", plt.Mountains, plt.Crystite)
+	}
+
+	// A map written out reads back the same
+	mq, err := ParseMap(strings.NewReader(mp.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mq.String() != mp.String() {
+		t.Errorf("the map changed on the way through:\n%s\n%s", mp, mq)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+
+	tests := []struct {
+		s          string
+		nrow, ncol int
+		ok         bool
+	}{
+		{"5x9", 5, 9, true},
+		{"3x5", 3, 5, true},
+		{"6x11", 6, 11, true},
+		{"2x9", 0, 0, false},
+		{"5x4", 0, 0, false},
+		{"7x9", 0, 0, false},
+		{"5x12", 0, 0, false},
+		{"5", 0, 0, false},
+		{"fivexnine", 0, 0, false},
+	}
+
+	for _, tc := range tests {
+		nrow, ncol, err := ParseSize(tc.s)
+		if tc.ok != (err == nil) || nrow != tc.nrow || ncol != tc.ncol {
+			t.Errorf("%q: got %dx%d, %v", tc.s, nrow, ncol, err)
+		}
+	}
+}
+
+func TestRandomFieldSize(t *testing.T) {
+
+	tests := []struct {
+		nrow, ncol int
+		w, h       int
+	}{
+		{0, 0, 9, 6},
+		{minRows, minCols, maxPlotWidth, maxPlotHeight},
+		{maxRows, maxCols, minPlotWidth, minPlotHeight},
+	}
+
+	for _, tc := range tests {
+		gi := &GameInfo{PlayerNames: []string{"ann"}, Seed: 1, Level: LevelStandard, Rows: tc.nrow, Cols: tc.ncol}
+		md := NewModel(gi)
+		if tc.nrow == 0 {
+			tc.nrow, tc.ncol = defaultRows, defaultCols
+		}
+		if md.nrow != tc.nrow || md.ncol != tc.ncol || len(md.plots) != tc.nrow*tc.ncol {
+			t.Errorf("%dx%d: laid out %dx%d with %d plots", tc.nrow, tc.ncol, md.nrow, md.ncol, len(md.plots))
+		}
+		if i, j := md.storePlot(); j == 0 || j == md.ncol-1 || i < 0 || i >= md.nrow {
+			t.Errorf("%dx%d: the store is at %d,%d", tc.nrow, tc.ncol, i, j)
+		}
+
+		// The field fits the screen
+		w, h := plotSize(tc.nrow, tc.ncol)
+		if w != tc.w || h != tc.h {
+			t.Errorf("%dx%d: plots are %dx%d, want %dx%d", tc.nrow, tc.ncol, w, h, tc.w, tc.h)
+		}
+		if w*tc.ncol > fieldWidth || h*tc.nrow > fieldHeight {
+			t.Errorf("%dx%d: the field is %dx%d characters", tc.nrow, tc.ncol, w*tc.ncol, h*tc.nrow)
+		}
+	}
+}
@@ -8,9 +8,24 @@ import (
 type Model struct {
 	mule *MULE
 
+	// All randomness in the game comes from here, so that a game can
+	// be reproduced from its seed
+	seed int64
+	src  *countingSource
+	rng  *rand.Rand
+
+	level Level
+	rules *Rules
+
 	// The field plots, stored row-wise
+	nrow  int
+	ncol  int
 	plots []*Plot
 
+	// Location of the store
+	storeRow int
+	storeCol int
+
 	Players []*Player
 
 	// Store prices
@@ -26,22 +41,19 @@ type Model struct {
 	storeSmithore int
 	storeCrystite int
 	storeMules    int
-}
 
-const (
-	// MULE outfitting costs
-	crystiteOutfitCost int = 100
-	smithoreOutfitCost int = 75
-	energyOutfitCost   int = 50
-	foodOutfitCost     int = 25
-)
+	// Rounds in which the colony as a whole did not have the food or
+	// energy it needed
+	starvedRounds int
+}
 
 type Player struct {
 	model *Model
 
 	pnum int
 
-	money int
+	money   int
+	species Species
 
 	// Quantities of goods
 	Food     int
@@ -98,6 +110,7 @@ const (
 	outfitResultAlreadyOutfitted
 	outfitResultNomoney
 	outfitResultSuccess
+	outfitResultUnavailable
 )
 
 type outfitType int
@@ -108,6 +121,9 @@ const (
 	outfitEnergy
 	outfitFood
 	outfitNone
+
+	// A MULE that outfits itself for the plot it is installed on
+	outfitAuto
 )
 
 type pubResult int
@@ -137,8 +153,36 @@ type Plot struct {
 	Crystite   int
 	Row        int
 	Col        int
+
+	// Put up for sale at the next land auction
+	ForSale bool
+
+	// The bonuses included in this round's production
+	bonuses productionBonus
 }
 
+type productionBonus int
+
+const (
+	// +1 for each learningPlots plots of the type that the owner has
+	bonusLearning productionBonus = 1 << iota
+
+	// +1 for each scalePlots plots of the type in the whole colony
+	bonusScale
+
+	// +1 for each of the four neighboring plots of the type with the
+	// same owner, so a plot in a block gains more than one on its edge
+	bonusAdjacent
+)
+
+const (
+	learningPlots = 3
+	scalePlots    = 4
+
+	// The most a plot can produce, bonuses included
+	maxProduction = 8
+)
+
 func (py *Player) updateScore(mg *MULE) {
 
 	score := py.money
@@ -187,38 +231,58 @@ func (md *Model) updateStorePrices(r int) {
 	if rat < 1 {
 		rat = 1
 	}
-	md.foodStorePrice = int(30 * (0.25 + 0.75*rat))
+	rl := md.rules
+	md.foodStorePrice = int(float64(rl.FoodPrice) * (0.25 + 0.75*rat))
 
 	rat = float64(reqEnergy) / float64(totEnergy)
 	if rat < 1 {
 		rat = 1
 	}
-	md.energyStorePrice = int(25 * (0.25 + 0.75*rat))
+	md.energyStorePrice = int(float64(rl.EnergyPrice) * (0.25 + 0.75*rat))
 
 	// smithore store price
-	mules := md.storeMules + md.storeSmithore/2 // mule equivalents in store
-	if mules >= 5 {
-		md.smithoreStorePrice = 50
+	mules := md.storeMules + md.storeSmithore/rl.SmithorePerMule // mule equivalents in store
+	if mules >= rl.ShortageMules {
+		md.smithoreStorePrice = rl.SmithorePrice
 	} else {
-		md.smithoreStorePrice = 50 + 100*(5-mules)
+		md.smithoreStorePrice = rl.SmithorePrice + rl.ShortagePrice*(rl.ShortageMules-mules)
 	}
-	x := int(rand.Int63() % 100)
-	switch {
-	case x <= 6:
-		md.smithoreStorePrice -= 14
-	case x <= 6+24:
-		md.smithoreStorePrice -= 7
-	case x <= 6+24+40:
-		// no change
-	case x <= 2+24+40+24:
-		md.smithoreStorePrice += 7
-	default:
-		md.smithoreStorePrice += 14
+	md.smithoreStorePrice += md.smithoreStep()
+
+	md.crystiteStorePrice = rl.CrystiteMinPrice + int(md.rng.Int63()%int64(rl.CrystiteRange))
+
+	if v := md.level.priceVariance(); v > 0 {
+		md.foodStorePrice = md.vary(md.foodStorePrice, v)
+		md.energyStorePrice = md.vary(md.energyStorePrice, v)
+		md.smithoreStorePrice = md.vary(md.smithoreStorePrice, v)
+		md.crystiteStorePrice = md.vary(md.crystiteStorePrice, v)
 	}
 
-	md.crystiteStorePrice = 50 + int(rand.Int63()%100)
+	md.muleStorePrice = rl.MulePriceFactor * md.smithoreStorePrice
+}
+
+// smithoreStep returns a random step in the smithore price, weighted
+// by the rules.
+func (md *Model) smithoreStep() int {
+	rl := md.rules
+	tot := 0
+	for _, w := range rl.SmithoreStepWeights {
+		tot += w
+	}
+	x := int(md.rng.Int63() % int64(tot))
+	for k, w := range rl.SmithoreStepWeights {
+		if x < w {
+			return rl.SmithoreSteps[k]
+		}
+		x -= w
+	}
+	return 0
+}
 
-	md.muleStorePrice = 2 * md.smithoreStorePrice
+// vary returns a price changed randomly by up to v percent.
+func (md *Model) vary(price, v int) int {
+	d := int(md.rng.Int63()%int64(2*v+1)) - v
+	return price * (100 + d) / 100
 }
 
 func (py *Player) getResourceByName(r string) int {
@@ -282,32 +346,44 @@ func (md *Model) getStoreSellPrice(rtp resourceType, r int) (int, int) {
 // that the player buys an extra energy for a mule to be purchased on
 // the next turn
 func (md *Model) updateRequiredEnergy(addone bool) {
-	for _, py := range md.Players {
-		if addone {
-			py.requiredEnergy = 1
-		} else {
-			py.requiredEnergy = 0
-		}
+	for p, py := range md.Players {
+		py.requiredEnergy = md.energyNeed(p, addone)
+	}
+}
+
+// energyNeed returns the energy player p needs for its MULEs, plus one
+// if addone is true.
+func (md *Model) energyNeed(p int, addone bool) int {
+	need := 0
+	if addone {
+		need = 1
 	}
 	for _, plt := range md.plots {
-		if plt.Owned {
+		if plt.Owned && plt.Owner == p {
 			if plt.MuleStatus == outfitFood || plt.MuleStatus == outfitSmithore || plt.MuleStatus == outfitCrystite {
-				md.Players[plt.Owner].requiredEnergy++
+				need++
 			}
 		}
 	}
+	return need
 }
 
 func (md *Model) updateRequiredFood(r int) {
 	for _, py := range md.Players {
-		py.requiredFood = 3 + r/4
+		py.requiredFood = foodNeed(r)
 	}
 }
 
+// foodNeed returns the food a player needs in round r.
+func foodNeed(r int) int {
+	return 3 + r/4
+}
+
 func (md *Model) MakeStoreMules() {
-	for md.storeMules < 14 {
-		if md.storeSmithore >= 2 {
-			md.storeSmithore -= 2
+	rl := md.rules
+	for md.storeMules < rl.MaxStoreMules {
+		if md.storeSmithore >= rl.SmithorePerMule {
+			md.storeSmithore -= rl.SmithorePerMule
 			md.storeMules++
 		} else {
 			break
@@ -318,12 +394,13 @@ func (md *Model) MakeStoreMules() {
 func (md *Model) DoProduction() {
 
 	// Base production of goods
-	for i := 0; i < nrow; i++ {
-		for j := 0; j < ncol; j++ {
+	for i := 0; i < md.nrow; i++ {
+		for j := 0; j < md.ncol; j++ {
 			plt := md.GetPlot(i, j)
-			plt.DoProduction()
+			plt.DoProduction(md.rng)
 		}
 	}
+	md.addProductionBonuses()
 
 	// Handle energy deficits
 	md.updateRequiredEnergy(false)
@@ -340,9 +417,9 @@ func (md *Model) DoProduction() {
 				}
 			}
 
-			for j := 0; j < ed; j++ {
-				q := int(rand.Int63() % int64(len(plv)))
-				plv[q].Production = 0
+			for j := 0; j < ed && len(plv) > 0; j++ {
+				q := int(md.rng.Int63() % int64(len(plv)))
+				plv[q].setProduction(0)
 				copy(plv[q:], plv[q+1:])
 				plv = plv[0 : len(plv)-1]
 			}
@@ -351,6 +428,12 @@ func (md *Model) DoProduction() {
 
 	// Add production to player totals
 	for _, plt := range md.plots {
+		if plt.Owned && plt.MuleStatus != outfitNone {
+			md.mule.logEvent(EventProduction, plt.Owner, "row", plt.Row, "col", plt.Col,
+				"good", goodName(plt.MuleStatus), "amount", plt.Production,
+				"learning", plt.bonuses&bonusLearning != 0, "scale", plt.bonuses&bonusScale != 0,
+				"adjacent", plt.bonuses&bonusAdjacent != 0)
+		}
 		if plt.Owned {
 			switch plt.MuleStatus {
 			case outfitFood:
@@ -366,6 +449,61 @@ func (md *Model) DoProduction() {
 	}
 }
 
+// addProductionBonuses adds the learning curve, economies of scale and
+// adjacency bonuses to the production of each plot.
+func (md *Model) addProductionBonuses() {
+
+	// Plots of each type in the colony, and owned by each player
+	colony := make(map[outfitType]int)
+	owned := make(map[[2]int]int)
+	for _, plt := range md.plots {
+		if plt.Owned && plt.MuleStatus != outfitNone {
+			colony[plt.MuleStatus]++
+			owned[[2]int{plt.Owner, int(plt.MuleStatus)}]++
+		}
+	}
+
+	for _, plt := range md.plots {
+		plt.bonuses = 0
+		if !plt.Owned || plt.MuleStatus == outfitNone || plt.Production == 0 {
+			continue
+		}
+		otp := plt.MuleStatus
+
+		if b := owned[[2]int{plt.Owner, int(otp)}] / learningPlots; b > 0 {
+			plt.Production += b
+			plt.bonuses |= bonusLearning
+		}
+
+		if b := colony[otp] / scalePlots; b > 0 {
+			plt.Production += b
+			plt.bonuses |= bonusScale
+		}
+
+		for _, q := range md.neighbors(plt) {
+			if q.Owned && q.Owner == plt.Owner && q.MuleStatus == otp {
+				plt.Production++
+				plt.bonuses |= bonusAdjacent
+			}
+		}
+		plt.setProduction(plt.Production)
+	}
+}
+
+// setProduction sets the production of a plot, up to maxProduction.
+// A plot that produces nothing has no bonuses.
+func (plt *Plot) setProduction(prod int) {
+	switch {
+	case prod <= 0:
+		plt.Production = 0
+		plt.bonuses = 0
+	case prod > maxProduction:
+		plt.Production = maxProduction
+	default:
+		plt.Production = prod
+	}
+}
+
 func (plt *Plot) baseProduction() int {
 
 	var base int
@@ -404,10 +542,25 @@ func (plt *Plot) baseProduction() int {
 	return base
 }
 
-func (plt *Plot) DoProduction() {
+// autoOutfit returns the good that a MULE produces most of on the
+// plot, not counting crystite.
+func (plt *Plot) autoOutfit() outfitType {
+	var best outfitType = outfitFood
+	bestp := -1
+	for _, otp := range []outfitType{outfitFood, outfitEnergy, outfitSmithore} {
+		q := *plt
+		q.MuleStatus = otp
+		if prod := q.baseProduction(); prod > bestp {
+			best, bestp = otp, prod
+		}
+	}
+	return best
+}
+
+func (plt *Plot) DoProduction(rng *rand.Rand) {
 
 	if plt.Owned == false || plt.MuleStatus == outfitNone {
-		plt.Production = 0
+		plt.setProduction(0)
 		return
 	}
 
@@ -416,7 +569,7 @@ func (plt *Plot) DoProduction() {
 	// Random term
 	var y int
 
-	x := int(rand.Int63() % 100)
+	x := int(rng.Int63() % 100)
 	switch {
 	case x < 6:
 		y = -2
@@ -430,14 +583,11 @@ func (plt *Plot) DoProduction() {
 		y = 2
 	}
 
-	prod := base + y
-	if prod < 0 {
-		prod = 0
-	}
-	if prod > 8 {
-		prod = 8
+	// The bonuses are added on top before the production is capped
+	plt.Production = base + y
+	if plt.Production < 0 {
+		plt.Production = 0
 	}
-	plt.Production = prod
 }
 
 func (py *Player) Outfit(otype outfitType) outfitResult {
@@ -447,19 +597,32 @@ func (py *Player) Outfit(otype outfitType) outfitResult {
 	if (py.muleOutfitType != outfitNone) && (py.muleOutfitType == otype) {
 		return outfitResultAlreadyOutfitted
 	}
+	if otype == outfitCrystite && !py.model.level.crystite() {
+		return outfitResultUnavailable
+	}
+
+	py.money -= py.model.outfitCost(otype)
+	py.model.mule.logEvent(EventMuleOutfitted, py.pnum, "good", goodName(otype),
+		"price", py.model.outfitCost(otype))
 
+	return outfitResultSuccess
+}
+
+func (md *Model) outfitCost(otype outfitType) int {
+	if md.level.freeOutfit() {
+		return 0
+	}
 	switch otype {
 	case outfitFood:
-		py.money -= foodOutfitCost
+		return md.rules.FoodOutfitCost
 	case outfitEnergy:
-		py.money -= energyOutfitCost
+		return md.rules.EnergyOutfitCost
 	case outfitSmithore:
-		py.money -= smithoreOutfitCost
+		return md.rules.SmithoreOutfitCost
 	case outfitCrystite:
-		py.money -= crystiteOutfitCost
+		return md.rules.CrystiteOutfitCost
 	}
-
-	return outfitResultSuccess
+	return 0
 }
 
 func (p *Player) gamblePub(r int, mg *MULE) (pubResult, int) {
@@ -468,21 +631,24 @@ func (p *Player) gamblePub(r int, mg *MULE) (pubResult, int) {
 	}
 
 	rb := 50 * (1 + r/4)
-	amt := rb + int(rand.Int63()%int64(mg.timeRemaining))
+	amt := rb + int(p.model.rng.Int63()%int64(mg.timeRemaining))
 	p.money += amt
+	mg.logEvent(EventPub, p.pnum, "money", amt)
 	return pubResultSuccess, amt
 }
 
 func (p *Player) BuyMule() buyResult {
-	if p.model.storeMules == 0 {
-		return buyResultNomules
-	}
+	// A MULE can be returned even when the store has none left
 	if p.hasMule {
 		p.model.storeMules++
 		p.money += p.model.muleStorePrice
 		p.hasMule = false
+		p.model.mule.logEvent(EventMuleReturned, p.pnum, "price", p.model.muleStorePrice)
 		return buyResultReturned
 	}
+	if p.model.storeMules == 0 {
+		return buyResultNomules
+	}
 	if p.model.muleStorePrice > p.money {
 		return buyResultNomoney
 	}
@@ -492,26 +658,39 @@ func (p *Player) BuyMule() buyResult {
 	p.hasMule = true
 	p.muleSymbol = 'M'
 	p.muleOutfitType = outfitNone
+	if p.model.level.preOutfitted() {
+		p.muleOutfitType = outfitAuto
+	}
+	p.model.mule.logEvent(EventMuleBought, p.pnum, "price", p.model.muleStorePrice)
 	return buyResultSuccess
 }
 
-func newDefaultPlayer(model *Model, p int) *Player {
+func newDefaultPlayer(model *Model, p int, sp Species) *Player {
 	var py Player
 	py.model = model
-	py.Food = 4
-	py.Energy = 2
-	py.money = 1000
+	py.species = sp
+	py.Food = model.rules.StartFood
+	py.Energy = model.rules.StartEnergy
+	py.money = sp.startMoney(model.rules)
 	py.pnum = p
 	return &py
 }
 
-func (md *Model) setupPlots() {
+// setupPlots lays out a random field of nrow by ncol plots, or of the
+// default size if either is zero.
+func (md *Model) setupPlots(nrow, ncol int) {
+
+	if nrow == 0 || ncol == 0 {
+		nrow, ncol = defaultRows, defaultCols
+	}
+	md.nrow, md.ncol = nrow, ncol
+	md.storeRow, md.storeCol = md.nrow/2, md.ncol/2
 
-	m := nrow * ncol
+	m := md.nrow * md.ncol
 	md.plots = make([]*Plot, m)
 	k := 0
-	for i := 0; i < nrow; i++ {
-		for j := 0; j < ncol; j++ {
+	for i := 0; i < md.nrow; i++ {
+		for j := 0; j < md.ncol; j++ {
 			md.plots[k] = new(Plot)
 			md.plots[k].MuleStatus = outfitNone
 			md.plots[k].Row = i
@@ -521,17 +700,17 @@ func (md *Model) setupPlots() {
 	}
 
 	// River status
-	for i := 0; i < nrow; i++ {
-		md.GetPlot(i, ncol/2).River = true
+	for i := 0; i < md.nrow; i++ {
+		md.GetPlot(i, md.ncol/2).River = true
 	}
 
 	// Add the mountain status
 	for _, lev := range []int{1, 2, 3} {
 		for ms := 0; ms < 4; {
-			k := int(rand.Int63() % int64(m))
+			k := int(md.rng.Int63() % int64(m))
 
 			// No mountains on the river or store
-			if k%ncol == ncol/2 {
+			if md.plots[k].River || md.isStore(k/md.ncol, k%md.ncol) {
 				continue
 			}
 
@@ -543,10 +722,13 @@ func (md *Model) setupPlots() {
 	}
 
 	// Add the Crystite deposits
-	ix := selectFrom(4, nrow*ncol)
+	if !md.level.crystite() {
+		return
+	}
+	ix := selectFrom(md.rng, 4, md.nrow*md.ncol)
 	for k := 0; k < 4; k++ {
-		i := ix[k] / ncol
-		j := ix[k] % ncol
+		i := ix[k] / md.ncol
+		j := ix[k] % md.ncol
 		md.addCrystite(i, j, 3)
 		md.addCrystite(i, j-1, 2)
 		md.addCrystite(i, j+1, 2)
@@ -564,17 +746,17 @@ func (md *Model) setupPlots() {
 }
 
 func (md *Model) addCrystite(i, j, v int) {
-	if i < 0 || i >= nrow {
+	if i < 0 || i >= md.nrow {
 		return
 	}
-	if j < 0 || j >= ncol {
+	if j < 0 || j >= md.ncol {
 		return
 	}
 	md.GetPlot(i, j).Crystite = v
 }
 
 // Randomly select k integers from m
-func selectFrom(k, m int) []int {
+func selectFrom(rng *rand.Rand, k, m int) []int {
 
 	v := make([]int, m)
 	for j := 0; j < m; j++ {
@@ -583,7 +765,7 @@ func selectFrom(k, m int) []int {
 
 	r := make([]int, k)
 	for j := 0; j < k; {
-		i := int(rand.Int63() % int64(m))
+		i := int(rng.Int63() % int64(m))
 		if v[i] != -1 {
 			r[j] = v[i]
 			j++
@@ -596,31 +778,47 @@ func selectFrom(k, m int) []int {
 
 func NewModel(gi *GameInfo) *Model {
 	md := new(Model)
+	md.seed = gi.Seed
+	md.src = newCountingSource(gi.Seed)
+	md.rng = rand.New(md.src)
+	md.level = gi.Level
+	md.rules = gi.Rules
+	if md.rules == nil {
+		md.rules = DefaultRules()
+	}
 
-	md.setupPlots()
+	if gi.Map != nil {
+		md.usePlots(gi.Map)
+	} else {
+		md.setupPlots(gi.Rows, gi.Cols)
+	}
 
 	// Setup players
 	md.Players = make([]*Player, len(gi.PlayerNames))
 	for p := 0; p < len(gi.PlayerNames); p++ {
-		md.Players[p] = newDefaultPlayer(md, p)
+		var sp Species
+		if p < len(gi.Species) {
+			sp = gi.Species[p]
+		}
+		md.Players[p] = newDefaultPlayer(md, p, sp)
 	}
 
 	// Initial values
-	md.storeMules = 14
-	md.muleStorePrice = 100
-	md.storeFood = 8
-	md.storeEnergy = 8
-	md.storeSmithore = 8
-	md.storeCrystite = 0
+	md.storeMules = md.rules.StoreMules
+	md.muleStorePrice = md.rules.StoreMulePrice
+	md.storeFood = md.rules.StoreFood
+	md.storeEnergy = md.rules.StoreEnergy
+	md.storeSmithore = md.rules.StoreSmithore
+	md.storeCrystite = md.rules.StoreCrystite
 
 	return md
 }
 
 func (md *Model) GetPlot(row, col int) *Plot {
-	if (row > nrow) || (col > ncol) {
+	if row < 0 || row >= md.nrow || col < 0 || col >= md.ncol {
 		panic("Invalid arguments to GetPlot")
 	}
-	return md.plots[row*ncol+col]
+	return md.plots[row*md.ncol+col]
 }
 
 func (md *Model) DoConsumptionSpoilage(r int) {
@@ -628,6 +826,18 @@ func (md *Model) DoConsumptionSpoilage(r int) {
 	md.updateRequiredFood(r)
 	md.updateRequiredEnergy(false)
 
+	// The colony starves if all its food or energy, including the
+	// store's, is not enough for everyone
+	food, energy := md.storeFood, md.storeEnergy
+	for _, py := range md.Players {
+		food += py.Food - py.requiredFood
+		energy += py.Energy - py.requiredEnergy
+	}
+	if food < 0 || energy < 0 {
+		md.starvedRounds++
+		md.mule.Logger.Printf("The colony is starving, short %d food and %d energy", -food, -energy)
+	}
+
 	for p := 0; p < md.mule.nplayers; p++ {
 		py := md.mule.Model.Players[p]
 
@@ -638,12 +848,14 @@ func (md *Model) DoConsumptionSpoilage(r int) {
 		}
 		py.Food -= x
 		if py.Food < 0 {
-			py.FoodDeficit = -x
+			py.FoodDeficit = -py.Food
 			py.Food = 0
 		} else {
 			py.FoodDeficit = 0
 		}
 		md.mule.Logger.Printf("Player %d lost %d food to consumption/spoilatge", p, x)
+		md.mule.logEvent(EventConsumption, p, "good", "food", "amount", x,
+			"spoiled", x-py.requiredFood, "deficit", py.FoodDeficit)
 
 		// Energy
 		x = py.requiredEnergy
@@ -652,12 +864,14 @@ func (md *Model) DoConsumptionSpoilage(r int) {
 		}
 		py.Energy -= x
 		if py.Energy < 0 {
-			py.EnergyDeficit = -x
+			py.EnergyDeficit = -py.Energy
 			py.Energy = 0
 		} else {
 			py.EnergyDeficit = 0
 		}
 		md.mule.Logger.Printf("Player %d lost %d energy to consumption/spoilage", p, x)
+		md.mule.logEvent(EventConsumption, p, "good", "energy", "amount", x,
+			"spoiled", x-py.requiredEnergy, "deficit", py.EnergyDeficit)
 	}
 }
 
@@ -696,13 +910,14 @@ func (md *Model) updatePlayerRanks() {
 	rk := make([]*rst, n)
 	for p := 0; p < n; p++ {
 		py := md.Players[p]
-		rk[p] = &rst{p, float64(py.score) + 0.01*rand.NormFloat64()}
+		rk[p] = &rst{p, float64(py.score) + 0.01*md.rng.NormFloat64()}
 	}
 
 	sort.Sort(rsl(rk))
 
-	for p := 0; p < n; p++ {
-		md.Players[p].rank = (n - rk[p].i) - 1
+	// Rank 0 is the highest score
+	for k := 0; k < n; k++ {
+		md.Players[rk[k].i].rank = n - 1 - k
 	}
 }
 
@@ -735,6 +950,42 @@ func (md *Model) randomOwnedPlot(maxrank int, types []outfitType) *Plot {
 		return nil
 	}
 
-	k := int(rand.Int63() % int64(len(players)))
+	k := int(md.rng.Int63() % int64(len(plv)))
 	return plv[k]
 }
+
+// storePlot returns the row and column of the plot holding the store.
+func (md *Model) storePlot() (int, int) {
+	return md.storeRow, md.storeCol
+}
+
+// isStore returns true if plot (i, j) holds the store.
+func (md *Model) isStore(i, j int) bool {
+	si, sj := md.storePlot()
+	return i == si && j == sj
+}
+
+// neighbors returns the plots that share a side with plt.
+func (md *Model) neighbors(plt *Plot) []*Plot {
+	var plv []*Plot
+	for _, d := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		i := plt.Row + d[0]
+		j := plt.Col + d[1]
+		if i >= 0 && i < md.nrow && j >= 0 && j < md.ncol {
+			plv = append(plv, md.GetPlot(i, j))
+		}
+	}
+	return plv
+}
+
+// countPlots returns the number of plots owned by player p that have
+// a MULE of type otp.
+func (md *Model) countPlots(p int, otp outfitType) int {
+	m := 0
+	for _, plt := range md.plots {
+		if plt.Owned && plt.Owner == p && plt.MuleStatus == otp {
+			m++
+		}
+	}
+	return m
+}
@@ -0,0 +1,55 @@
+package mule
+
+import "testing"
+
+func TestProductionBonuses(t *testing.T) {
+
+	mg, _, _, _ := newTestGame()
+	md := mg.Model
+	for _, plt := range md.plots {
+		plt.Owned = false
+		plt.MuleStatus = outfitNone
+		plt.Production = 0
+	}
+
+	// ann has four food plots in a corner, one of which produced
+	// nothing, and bob has one
+	own := func(i, j, p int, otp outfitType, prod int) *Plot {
+		plt := md.GetPlot(i, j)
+		plt.Owned, plt.Owner, plt.MuleStatus, plt.Production = true, p, otp, prod
+		return plt
+	}
+	corner := own(0, 0, 0, outfitFood, 2)
+	right := own(0, 1, 0, outfitFood, 7)
+	below := own(1, 0, 0, outfitFood, 2)
+	lost := own(0, 2, 0, outfitFood, 0)
+	bob := own(4, 8, 1, outfitFood, 2)
+	energy := own(1, 1, 0, outfitEnergy, 2)
+	md.addProductionBonuses()
+
+	tests := []struct {
+		name    string
+		plt     *Plot
+		prod    int
+		bonuses productionBonus
+	}{
+		{"corner", corner, 2 + 1 + 1 + 2, bonusLearning | bonusScale | bonusAdjacent},
+		{"capped", right, maxProduction, bonusLearning | bonusScale | bonusAdjacent},
+		{"below", below, 2 + 1 + 1 + 1, bonusLearning | bonusScale | bonusAdjacent},
+		{"lost", lost, 0, 0},
+		{"bob's", bob, 2 + 1, bonusScale},
+		{"energy", energy, 2, 0},
+	}
+	for _, tc := range tests {
+		if tc.plt.Production != tc.prod || tc.plt.bonuses != tc.bonuses {
+			t.Errorf("%s plot: produced %d with bonuses %b, want %d with %b",
+				tc.name, tc.plt.Production, tc.plt.bonuses, tc.prod, tc.bonuses)
+		}
+	}
+
+	// A plot that loses its production loses its bonuses
+	corner.setProduction(0)
+	if corner.bonuses != 0 {
+		t.Errorf("a plot that produced nothing kept bonuses %b", corner.bonuses)
+	}
+}
@@ -3,12 +3,14 @@
 package mule
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"strconv"
+	"strings"
 	"time"
-
-	"github.com/nsf/termbox-go"
 )
 
 type stage int
@@ -21,20 +23,64 @@ const (
 )
 
 const (
-	// Rows and columns of plots in the field
-	nrow = 5
-	ncol = 9
-
-	// width, height of a plot
-	ploth = 6
-	plotw = 9
+	// Rows and columns of plots in a random field
+	defaultRows = 5
+	defaultCols = 9
+
+	// Screen area that the plots are scaled to fill, and the limits
+	// on the plot size
+	fieldWidth    = 81
+	fieldHeight   = 30
+	minPlotWidth  = 7
+	maxPlotWidth  = 16
+	minPlotHeight = 5
+	maxPlotHeight = 10
 
 	statusbar_y int = 2
 )
 
+// MaxPlayers is the most players a game can have.
+const MaxPlayers = 8
+
+// The player colors, in seat order.  There are only six colors to go
+// round, so the last two players are shown in reverse video.
+var playerColors = []Attribute{ColorRed, ColorGreen, ColorYellow, ColorMagenta,
+	ColorCyan, ColorBlue | AttrBold, ColorYellow | AttrReverse, ColorCyan | AttrReverse}
+
+// DefaultPlayerColors returns the colors of the players in a game of n
+// players.
+func DefaultPlayerColors(n int) []Attribute {
+	return playerColors[:n]
+}
+
 type GameInfo struct {
 	PlayerNames  []string
-	PlayerColors []termbox.Attribute
+	PlayerColors []Attribute
+
+	// Which players are controlled by the computer
+	Computer []bool
+
+	// The species of each player, Mechtrons if missing
+	Species []Species
+
+	// Seed for the random number generator
+	Seed int64
+
+	Level Level
+
+	// House rules, nil for the default rules
+	Rules *Rules
+
+	// Layout of the field, nil for a random layout
+	Map *Map
+
+	// Rows and columns of plots in a random field, the default size
+	// if zero
+	Rows int
+	Cols int
+
+	// The clock the game runs on, nil for the wall clock
+	Clock Clock `json:"-"`
 }
 
 type MULE struct {
@@ -48,15 +94,25 @@ type MULE struct {
 	w int
 	h int
 
+	// width, height of a plot, and the offset of the MULE location
+	// within a plot
+	plotw int
+	ploth int
+	starH int
+	starV int
+
 	// Upper left corner of main region
 	x0 int
 	y0 int
 
 	PlayerNames  []string
-	PlayerColors []termbox.Attribute
+	PlayerColors []Attribute
 	nplayers     int
 
-	wumpusStatus chan wumpusInfo
+	// Strategies for the computer players, nil for human players
+	PlotChoosers []PlotChooser
+	TurnDrivers  []TurnDriver
+	Bidders      []Bidder
 
 	hasAssay bool
 	assay_x  int
@@ -65,24 +121,47 @@ type MULE struct {
 	currentStage  stage
 	round         int
 	timeRemaining int
+	timeMsg       string // as shown on the status bar
+
+	// If not empty, the game is saved here at the end of each round
+	SaveFile string
+
+	eventQueue chan Action
+
+	// Counts the times the game starts waiting for input, for
+	// recording and replaying games
+	phase    phaseState
+	recorder *json.Encoder
 
-	eventQueue chan termbox.Event
+	// What the game is blocked on, nil unless it is being replayed
+	quiet *quiet
+
+	// Everything that is drawn goes through the Renderer
+	Renderer Renderer
 
 	Logger *log.Logger
 
+	// All timers and pauses go through the Clock, which stops while
+	// the game is paused
+	Clock Clock
+	pause *pauseClock
+
+	// If not nil, the events of the game are logged here as JSON
+	// lines
+	Events io.Writer
+
 	// The keys are player event numbers that have already been selected
 	playerEventHappened map[int]bool
 
 	// Count the number of times each round event occured
 	roundEventCounts []int
 
-	timerinfo chan string
-	mainTimer *time.Timer
-	Timers    []*time.Timer
+	// The clock of a human player's turn, nil when not in one
+	turnTimer *turnTimer
 }
 
 func NewMule(md *Model, sv *StoreView, fv *FieldView, av *AuctionView,
-	q chan termbox.Event, gi *GameInfo) *MULE {
+	q chan Action, gi *GameInfo) *MULE {
 
 	mg := new(MULE)
 	mg.Model = md
@@ -90,18 +169,41 @@ func NewMule(md *Model, sv *StoreView, fv *FieldView, av *AuctionView,
 	mg.Fieldview = fv
 	mg.Auctionview = av
 	mg.eventQueue = q
+	mg.Renderer = NullRenderer{}
+	mg.Logger = log.New(ioutil.Discard, "", 0)
+	var base Clock = RealClock{}
+	if gi.Clock != nil {
+		base = gi.Clock
+	}
+	mg.pause = newPauseClock(base)
+	mg.Clock = mg.pause
 
 	mg.PlayerNames = gi.PlayerNames
 	mg.PlayerColors = gi.PlayerColors
 	mg.nplayers = len(gi.PlayerNames)
 
+	mg.PlotChoosers = make([]PlotChooser, mg.nplayers)
+	mg.TurnDrivers = make([]TurnDriver, mg.nplayers)
+	mg.Bidders = make([]Bidder, mg.nplayers)
+	for p := 0; p < mg.nplayers; p++ {
+		if p < len(gi.Computer) && gi.Computer[p] {
+			c := NewComputer()
+			mg.PlotChoosers[p] = c
+			mg.TurnDrivers[p] = c
+			mg.Bidders[p] = c
+		}
+	}
+
 	// upper left corner of play region
 	mg.x0 = 2
 	mg.y0 = 4
 
 	// width, height of field
-	mg.w = plotw * ncol
-	mg.h = ploth*nrow + 4
+	mg.plotw, mg.ploth = plotSize(md.nrow, md.ncol)
+	mg.starH = mg.plotw / 2
+	mg.starV = mg.ploth / 2
+	mg.w = mg.plotw * md.ncol
+	mg.h = mg.ploth*md.nrow + 4
 
 	// Set this as the parent of these components
 	md.mule = mg
@@ -115,68 +217,138 @@ func NewMule(md *Model, sv *StoreView, fv *FieldView, av *AuctionView,
 	mg.playerEventHappened = make(map[int]bool)
 	mg.roundEventCounts = make([]int, 8)
 
-	mg.timerinfo = make(chan string)
-
-	mg.wumpusStatus = make(chan wumpusInfo)
-	go wumpusManager(mg)
-
 	return mg
 }
 
-func (mg *MULE) WaitForSpace() {
-	time.Sleep(100 * time.Millisecond)
+// WaitForSpace waits for player p to press space, or for every human
+// player to if p is AnyPlayer, so that nobody is hurried past a message
+// by another player.
+func (mg *MULE) WaitForSpace(p int) {
+	mg.startPhase()
+	mg.sleep(100 * time.Millisecond)
 	mg.drainQueue()
+	ready := mg.newVotes()
 	for {
-		select {
-		case ev := <-mg.eventQueue:
-			if ev.Type == termbox.EventKey && ev.Key == termbox.KeySpace {
-				return
-			}
-		default:
-			time.Sleep(100 * time.Millisecond)
+		a := <-mg.input()
+		mg.took(a)
+		if a.Type != ActionConfirm {
+			continue
+		}
+		if p == AnyPlayer && ready.add(a) || p != AnyPlayer && a.from(p) {
+			return
+		}
+	}
+}
+
+// votes collects the human players that have asked for something that
+// needs all of them, e.g. to end an auction early.  An action from
+// AnyPlayer, the shared keyboard, speaks for all of them.
+type votes struct {
+	mg    *MULE
+	asked []bool
+}
+
+func (mg *MULE) newVotes() *votes {
+	return &votes{mg: mg, asked: make([]bool, mg.nplayers)}
+}
+
+// add counts the vote of a's player, and returns true once every human
+// player has voted.
+func (v *votes) add(a Action) bool {
+	if a.Player == AnyPlayer {
+		return true
+	}
+	if p := a.player(v.mg.nplayers); p >= 0 {
+		v.asked[p] = true
+	}
+	for p, ok := range v.asked {
+		if !ok && v.mg.TurnDrivers[p] == nil {
+			return false
 		}
 	}
+	return true
 }
 
+// drainQueue throws away the inputs that are waiting, apart from a
+// pause, which pauses the game rather than being lost.
 func (mg *MULE) drainQueue() {
 	for {
 		select {
-		case <-mg.eventQueue:
+		case a := <-mg.eventQueue:
+			if a.Type == ActionPause {
+				mg.took(a)
+				continue
+			}
+			mg.record(a, true)
 		default:
 			return
 		}
 	}
 }
 
-func timeFunc(mg *MULE, tm int) func() {
-	f := func() {
-		mg.timeRemaining = tm
-		var msg string
-		msg = fmt.Sprintf("Time: %2ds", tm)
-		mg.timerinfo <- msg
+// sleep waits for d on the game clock, e.g. while a banner is shown or
+// a computer player moves.  Inputs that come meanwhile are thrown away,
+// apart from a pause, which pauses the game straight away.
+func (mg *MULE) sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	timer := mg.Clock.NewTimer(d)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C():
+			mg.woke(timer.C())
+			return
+		case a := <-mg.input(timer.C()):
+			if a.Type == ActionPause {
+				mg.took(a)
+				continue
+			}
+			mg.quiet.woke(mg.eventQueue)
+			mg.record(a, true)
+		}
 	}
-	return f
 }
 
-func (mg *MULE) setupTimer(py *Player) {
-
-	mg.mainTimer = time.NewTimer(time.Duration(py.availableTime) * time.Second)
+// turnTimer counts down the seconds of a player's turn.  The turn's
+// event loop works out the time left from the clock at each tick, so
+// that ticks dropped while the game is busy elsewhere don't count.
+type turnTimer struct {
+	tick Ticker
+	end  time.Time
+}
 
-	for j := 0; j <= py.availableTime; j += 1 {
-		f := timeFunc(mg, py.availableTime-j)
-		t := time.AfterFunc(time.Duration(j)*time.Second, f)
-		mg.Timers = append(mg.Timers, t)
+// startTurnTimer starts the clock of a turn of secs seconds.
+func (mg *MULE) startTurnTimer(secs int) {
+	mg.stopTurnTimer()
+	mg.turnTimer = &turnTimer{
+		tick: mg.Clock.NewTicker(time.Second),
+		end:  mg.Clock.Now().Add(time.Duration(secs) * time.Second),
 	}
 }
 
-func (mg *MULE) ClearTimers() {
-	if mg.mainTimer != nil {
-		mg.mainTimer.Stop()
+// stopTurnTimer stops the clock of the current turn, if there is one.
+func (mg *MULE) stopTurnTimer() {
+	if mg.turnTimer != nil {
+		mg.turnTimer.tick.Stop()
+		mg.turnTimer = nil
 	}
-	for _, t := range mg.Timers {
-		t.Stop()
+}
+
+// turnTick returns the ticks of the turn's clock, nil, and so never
+// ready, when no turn is being timed.
+func (mg *MULE) turnTick() <-chan time.Time {
+	if mg.turnTimer == nil {
+		return nil
 	}
-	mg.Timers = mg.Timers[:0]
+	return mg.turnTimer.tick.C()
+}
+
+// turnLeft returns the whole seconds left in the turn, rounded up.
+func (mg *MULE) turnLeft() int {
+	d := mg.turnTimer.end.Sub(mg.Clock.Now())
+	return int((d + time.Second - 1) / time.Second)
 }
 
 func (mg *MULE) PlayerTurn(p, r int) {
@@ -187,8 +359,14 @@ func (mg *MULE) PlayerTurn(p, r int) {
 
 	py := mg.Model.Players[p]
 	py.availableTime = mg.Model.playerTurnTime(p, r)
-	mg.ClearTimers()
-	mg.setupTimer(py)
+	mg.stopTurnTimer()
+
+	// Computer players keep track of their own time
+	td := mg.TurnDrivers[p]
+	if td == nil {
+		mg.timeRemaining = py.availableTime
+		mg.startTurnTimer(py.availableTime)
+	}
 	mg.Fieldview.PrintTime(fmt.Sprintf("Time: %2ds    ", py.availableTime))
 	mg.hasAssay = false
 
@@ -196,24 +374,39 @@ func (mg *MULE) PlayerTurn(p, r int) {
 	mg.updateStatusBar(p)
 	mg.Storeview.initLive(p, locStoreLeft)
 	mg.Storeview.DrawStore()
-	termbox.Flush()
+	mg.Renderer.Flush()
 	evx := mg.genEvent(p, r)
 	if evx != "" {
 		mg.Logger.Printf("Player %d: %s\n", p, evx)
+		mg.logEvent(EventPlayer, p, "message", evx)
 		mg.Banner(mg.PlayerNames[p]+": "+evx, 0)
 		mg.Banner("", 1)
-		termbox.Flush()
-		time.Sleep(3 * time.Second)
+		mg.Renderer.Flush()
+		mg.sleep(3 * time.Second)
+	}
+
+	if td != nil {
+		mg.currentStage = stageLiveField
+		mg.Storeview.Clear()
+		mg.Fieldview.DrawLandscape()
+		mg.Fieldview.DrawOwnedPlots()
+		td.PlayTurn(mg, p, r)
+		mg.Banner("", 0)
+		mg.Banner("", 1)
+		return
+	}
+
+	if evx != "" {
 		mg.Banner("Press space to start", 1)
-		termbox.Flush()
+		mg.Renderer.Flush()
 	} else {
 		msg := fmt.Sprintf("%s -- press space to start",
 			mg.PlayerNames[p])
 		mg.Banner(msg, 0)
 		mg.Banner("", 1)
-		termbox.Flush()
+		mg.Renderer.Flush()
 	}
-	mg.WaitForSpace()
+	mg.WaitForSpace(p)
 	mg.Banner("", 0)
 	mg.Banner("", 1)
 
@@ -238,7 +431,7 @@ func (mg *MULE) PlayerTurn(p, r int) {
 			break
 		}
 	}
-	mg.ClearTimers()
+	mg.stopTurnTimer()
 }
 
 func (mg *MULE) PlotSelection(r int) {
@@ -249,8 +442,8 @@ func (mg *MULE) PlotSelection(r int) {
 	mg.clearStatusBar()
 	mg.Fieldview.SelectPlot(r)
 	mg.Fieldview.DrawOwnedPlots()
-	termbox.Flush()
-	time.Sleep(2000 * time.Millisecond)
+	mg.Renderer.Flush()
+	mg.sleep(2000 * time.Millisecond)
 }
 
 func (mg *MULE) DoProduction(r int) {
@@ -265,8 +458,9 @@ func (mg *MULE) DoProduction(r int) {
 
 	msg := fmt.Sprintf("Round %d production, press space to continue", r+1)
 	mg.Banner(msg, 0)
-	termbox.Flush()
-	mg.WaitForSpace()
+	mg.Banner("Bonuses: L learning curve, S economies of scale, A neighboring plots", 1)
+	mg.Renderer.Flush()
+	mg.WaitForSpace(AnyPlayer)
 }
 
 func (mg *MULE) DoAuction(r int) {
@@ -298,15 +492,36 @@ func (mg *MULE) DoAuction(r int) {
 	}
 }
 
+// plotSize returns the width and height of a plot, scaled so that the
+// field fills about the same area whatever its size.
+func plotSize(nrow, ncol int) (int, int) {
+	w := fieldWidth / ncol
+	if w < minPlotWidth {
+		w = minPlotWidth
+	} else if w > maxPlotWidth {
+		w = maxPlotWidth
+	}
+	h := fieldHeight / nrow
+	if h < minPlotHeight {
+		h = minPlotHeight
+	} else if h > maxPlotHeight {
+		h = maxPlotHeight
+	}
+	return w, h
+}
+
 func (mg *MULE) Play() {
 
-	// Loop over rounds
-	for r := 0; r < 12; r++ {
+	// Loop over rounds, starting from a restored round if the game
+	// was resumed
+	rounds := mg.Model.level.rounds()
+	for r := mg.round; r < rounds; r++ {
 
 		mg.round = r
 		mg.Logger.Printf("Starting round %d", r+1)
 
 		mg.PlotSelection(r)
+		mg.LandAuction(r)
 
 		for p := 0; p < len(mg.PlayerNames); p++ {
 			mg.PlayerTurn(p, r)
@@ -315,7 +530,7 @@ func (mg *MULE) Play() {
 		mg.Model.DoConsumptionSpoilage(r)
 		mg.DoProduction(r)
 		mg.DoRoundEvent(r)
-		if r < 11 {
+		if r < rounds-1 {
 			mg.DoAuction(r)
 		}
 
@@ -325,31 +540,65 @@ func (mg *MULE) Play() {
 		mg.Model.MakeStoreMules()
 
 		mg.DoLeaderboard()
+
+		if mg.SaveFile != "" && r < rounds-1 {
+			mg.round = r + 1
+			if err := mg.Save(mg.SaveFile); err != nil {
+				mg.Logger.Printf("Unable to save game: %v", err)
+			} else {
+				mg.Logger.Printf("Saved game to %s", mg.SaveFile)
+			}
+		}
 	}
+
+	mg.DoFinalSummary()
 }
 
 func (mg *MULE) Banner(msg string, y int) {
 	for k, c := range msg {
-		termbox.SetCell(k+2, y, c, termbox.ColorWhite, termbox.ColorBlack)
+		mg.Renderer.SetCell(k+2, y, c, ColorWhite, ColorBlack)
 	}
 	for k := len(msg); k < 100; k++ {
-		termbox.SetCell(k+2, y, ' ', termbox.ColorBlack, termbox.ColorBlack)
+		mg.Renderer.SetCell(k+2, y, ' ', ColorBlack, ColorBlack)
+	}
+	mg.Renderer.Flush()
+}
+
+// SecretBanner is like Banner, but viewers that may not see hidden
+// information are shown cover instead of msg.
+func (mg *MULE) SecretBanner(msg, cover string, y int) {
+	sr, ok := mg.Renderer.(SecretRenderer)
+	if !ok {
+		mg.Banner(msg, y)
+		return
+	}
+	m := []rune(msg)
+	c := []rune(cover)
+	for k := 0; k < 100; k++ {
+		mc, cc := ' ', ' '
+		if k < len(m) {
+			mc = m[k]
+		}
+		if k < len(c) {
+			cc = c[k]
+		}
+		sr.SetSecretCell(k+2, y, mc, cc, ColorWhite, ColorBlack)
 	}
-	termbox.Flush()
+	mg.Renderer.Flush()
 }
 
-func (mg *MULE) Print(x, y int, msg string, fg, bg termbox.Attribute) {
+func (mg *MULE) Print(x, y int, msg string, fg, bg Attribute) {
 	for k, c := range msg {
-		termbox.SetCell(x+k, y, c, fg, bg)
+		mg.Renderer.SetCell(x+k, y, c, fg, bg)
 	}
-	termbox.Flush()
+	mg.Renderer.Flush()
 }
 
-func (mg *MULE) PrintMain(x, y int, msg string, fg, bg termbox.Attribute) {
+func (mg *MULE) PrintMain(x, y int, msg string, fg, bg Attribute) {
 	for k, c := range msg {
-		termbox.SetCell(mg.x0+x+k, mg.y0+y, c, fg, bg)
+		mg.Renderer.SetCell(mg.x0+x+k, mg.y0+y, c, fg, bg)
 	}
-	termbox.Flush()
+	mg.Renderer.Flush()
 }
 
 func GetGameInfo() *GameInfo {
@@ -357,18 +606,20 @@ func GetGameInfo() *GameInfo {
 	var nplayers int
 
 	for {
-		fmt.Print("\nHow many players (2-4): ")
+		fmt.Printf("\nHow many players (1-%d): ", MaxPlayers)
 		var nps string
 		fmt.Scanln(&nps)
 		var err error
 		nplayers, err = strconv.Atoi(nps)
-		if err == nil && nplayers >= 2 && nplayers <= 4 {
+		if err == nil && nplayers >= 1 && nplayers <= MaxPlayers {
 			break
 		}
-		fmt.Printf("You must enter a number between 2 and 4\n")
+		fmt.Printf("You must enter a number between 1 and %d\n", MaxPlayers)
 	}
 
 	pnms := make([]string, nplayers)
+	comp := make([]bool, nplayers)
+	spec := make([]Species, nplayers)
 
 	for j := 0; j < nplayers; j++ {
 		for {
@@ -378,10 +629,48 @@ func GetGameInfo() *GameInfo {
 				break
 			}
 		}
+		fmt.Printf("Is %s played by the computer (y/n)? ", pnms[j])
+		var yn string
+		fmt.Scanln(&yn)
+		comp[j] = strings.HasPrefix(strings.ToLower(yn), "y")
+
+		for {
+			fmt.Printf("What species is %s (%s) [mechtron]? ", pnms[j], strings.Join(speciesNames(), ", "))
+			var sn string
+			fmt.Scanln(&sn)
+			if sn == "" {
+				break
+			}
+			var err error
+			spec[j], err = ParseSpecies(sn)
+			if err == nil {
+				break
+			}
+			fmt.Printf("%v\n", err)
+		}
+	}
+
+	var level Level
+	for {
+		fmt.Print("\nLevel (beginner, standard, tournament) [standard]: ")
+		var lv string
+		fmt.Scanln(&lv)
+		if lv == "" {
+			break
+		}
+		var err error
+		level, err = ParseLevel(lv)
+		if err == nil {
+			break
+		}
+		fmt.Printf("%v\n", err)
 	}
 
 	gi := new(GameInfo)
 	gi.PlayerNames = pnms
+	gi.Computer = comp
+	gi.Species = spec
+	gi.Level = level
 
 	return gi
 }
@@ -393,19 +682,20 @@ func (mg *MULE) updateStatusBar(p int) {
 	h := "Money: %5d Food: %2d Energy: %2d Smithore: %2d Crystite: %2d"
 	s := fmt.Sprintf(h, py.money, py.Food, py.Energy, py.Smithore, py.Crystite)
 
-	fg := termbox.ColorWhite
-	bg := termbox.ColorBlack
+	fg := ColorWhite
+	bg := ColorBlack
 
 	for k, c := range s {
-		termbox.SetCell(2+k, statusbar_y, c, fg, bg)
+		mg.Renderer.SetCell(2+k, statusbar_y, c, fg, bg)
 	}
-	termbox.Flush()
+	mg.Renderer.Flush()
 }
 
 func (mg *MULE) clearStatusBar() {
-	bg := termbox.ColorBlack
+	mg.timeMsg = ""
+	bg := ColorBlack
 	for k := 0; k < 100; k++ {
-		termbox.SetCell(k, statusbar_y, ' ', bg, bg)
+		mg.Renderer.SetCell(k, statusbar_y, ' ', bg, bg)
 	}
-	termbox.Flush()
+	mg.Renderer.Flush()
 }
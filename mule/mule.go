@@ -1,20 +1,212 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
-	"math/rand"
+	"net"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/EmmaShedden/mule"
 	"github.com/nsf/termbox-go"
 )
 
+var (
+	seed   = flag.Int64("seed", time.Now().UnixNano(), "seed for the random number generator")
+	save   = flag.String("save", "mule.save", "save the game here at the end of each round")
+	resume = flag.String("resume", "", "resume the game saved in this file")
+	rules  = flag.String("rules", "", "play with the house rules in this JSON file")
+	mapf   = flag.String("map", "", "play on the field laid out in this map file")
+	size   = flag.String("size", "", "size of a random field as rows by columns, e.g. 6x11")
+	events = flag.String("events", "mule.events", "log the game's events here as JSON lines, none if empty, a replay only logs them if given")
+	record = flag.String("record", "mule.replay", "record the game's inputs here for replay, none if empty")
+
+	headless = flag.Bool("headless", false, "replay as fast as possible without drawing the game")
+	speed    = flag.Float64("speed", 1, "replay this many times faster than the game was played")
+
+	spectate = flag.String("spectate", "", "also accept spectators at this address when serving")
+	reveal   = flag.Bool("reveal", false, "show hidden information, e.g. assay results, to spectators")
+)
+
+const defaultAddr = ":7777"
+
+// The game's log, a replay has its own so that the log of the game
+// that was recorded is kept
+var logName = "mule.log"
+
 func main() {
 
-	rand.Seed(time.Now().UnixNano())
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  mule [flags]                  play on this terminal\n")
+		fmt.Fprintf(os.Stderr, "  mule [flags] serve [addr]     host a game over the network\n")
+		fmt.Fprintf(os.Stderr, "  mule join host:port           join a hosted game\n")
+		fmt.Fprintf(os.Stderr, "  mule watch host:port          watch a hosted game\n")
+		fmt.Fprintf(os.Stderr, "  mule [flags] replay file      replay a recorded game\n")
+		fmt.Fprintf(os.Stderr, "Addresses of the form unix:path are local sockets.\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	switch flag.Arg(0) {
+	case "":
+		play()
+	case "serve":
+		addr := flag.Arg(1)
+		if addr == "" {
+			addr = defaultAddr
+		}
+		serve(addr)
+	case "join":
+		join(flag.Arg(1))
+	case "watch":
+		watch(flag.Arg(1))
+	case "replay":
+		replay(flag.Arg(1))
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// network returns the network and address to use for an address
+// given on the command line.
+func network(addr string) (string, string) {
+	if strings.HasPrefix(addr, "unix:") {
+		return "unix", strings.TrimPrefix(addr, "unix:")
+	}
+	return "tcp", addr
+}
+
+func listen(addr string) net.Listener {
+	ln, err := net.Listen(network(addr))
+	if err != nil {
+		panic(err)
+	}
+	return ln
+}
+
+func dial(addr string) net.Conn {
+	if addr == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	conn, err := net.Dial(network(addr))
+	if err != nil {
+		panic(err)
+	}
+	return conn
+}
+
+// gameInfo gets the game setup from a saved game if resuming, or from
+// the user otherwise.  A saved game keeps its rules unless others are
+// given.
+func gameInfo() (*mule.GameInfo, *mule.SavedGame) {
+
+	var gameinfo *mule.GameInfo
+	var saved *mule.SavedGame
+	if *resume != "" {
+		var err error
+		saved, err = mule.LoadGame(*resume)
+		if err != nil {
+			panic(err)
+		}
+		gameinfo = saved.GameInfo()
+	} else {
+		gameinfo = mule.GetGameInfo()
+		gameinfo.Seed = *seed
+		if *mapf != "" {
+			mp, err := mule.LoadMap(*mapf)
+			if err != nil {
+				panic(err)
+			}
+			gameinfo.Map = mp
+		} else if *size != "" {
+			var err error
+			gameinfo.Rows, gameinfo.Cols, err = mule.ParseSize(*size)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	if *rules != "" {
+		rl, err := mule.LoadRules(*rules)
+		if err != nil {
+			panic(err)
+		}
+		gameinfo.Rules = rl
+	}
+
+	return gameinfo, saved
+}
+
+// newGame sets up the game, logging to logName and the events file.
+// The returned function closes the logs.
+func newGame(gameinfo *mule.GameInfo, saved *mule.SavedGame, eventQueue chan mule.Action) (*mule.MULE, func()) {
+
+	gameinfo.PlayerColors = mule.DefaultPlayerColors(len(gameinfo.PlayerNames))
 
-	gameinfo := mule.GetGameInfo()
+	mm := mule.NewModel(gameinfo)
+	sv := mule.NewStoreView()
+	fv := mule.NewFieldView()
+	av := mule.NewAuctionView()
+	mg := mule.NewMule(mm, sv, fv, av, eventQueue, gameinfo)
+	mg.SaveFile = *save
+
+	// Keep the log of the earlier part of a resumed game
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if saved != nil {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	fid, err := os.OpenFile(logName, flags, 0644)
+	if err != nil {
+		panic(err)
+	}
+	mg.Logger = log.New(fid, "", log.Lshortfile)
+	closers := []*os.File{fid}
+
+	if *events != "" {
+		efid, err := os.OpenFile(*events, flags, 0644)
+		if err != nil {
+			panic(err)
+		}
+		mg.Events = efid
+		closers = append(closers, efid)
+	}
+	mg.Logger.Printf("Random seed %d", gameinfo.Seed)
+
+	if saved != nil {
+		mg.Restore(saved)
+		mg.Logger.Printf("Resumed game from %s", *resume)
+	}
+
+	if *record != "" {
+		rfid, err := os.Create(*record)
+		if err != nil {
+			panic(err)
+		}
+		if err := mg.Record(rfid, gameinfo, saved); err != nil {
+			panic(err)
+		}
+		closers = append(closers, rfid)
+	}
+	mg.Logger.Printf("Map:\n%s", mm.Map())
+
+	return mg, func() {
+		for _, f := range closers {
+			f.Close()
+		}
+	}
+}
+
+// play runs a game with all players sharing this terminal.
+func play() {
+
+	gameinfo, saved := gameInfo()
 
 	err := termbox.Init()
 	if err != nil {
@@ -22,9 +214,10 @@ func main() {
 	}
 	defer termbox.Close()
 
-	eventQueue := make(chan termbox.Event)
+	eventQueue := make(chan mule.Action)
 
-	// Capture and pre-screen the events
+	// Capture and pre-screen the events, then translate the keys
+	// into player actions
 	go func() {
 		for {
 			x := termbox.PollEvent()
@@ -33,29 +226,219 @@ func main() {
 			if x.Type == termbox.EventKey {
 				if x.Key == termbox.KeyCtrlC {
 					termbox.Close()
+					if _, err := os.Stat(*save); err == nil {
+						fmt.Printf("Use --resume %s to continue from the last completed round\n", *save)
+					}
 					os.Exit(0)
 				}
 			}
 
-			eventQueue <- x
+			for _, a := range mule.TermboxActions(x) {
+				eventQueue <- a
+			}
 		}
 	}()
 
-	gameinfo.PlayerColors = []termbox.Attribute{termbox.ColorRed,
-		termbox.ColorGreen, termbox.ColorYellow, termbox.ColorMagenta}
+	mg, done := newGame(gameinfo, saved, eventQueue)
+	defer done()
+	mg.Renderer = mule.TermboxRenderer{}
 
-	mm := mule.NewModel(gameinfo)
-	sv := mule.NewStoreView()
-	fv := mule.NewFieldView()
-	av := mule.NewAuctionView()
-	mg := mule.NewMule(mm, sv, fv, av, eventQueue, gameinfo)
+	mg.Play()
+}
+
+// serve hosts a game, waiting for one remote player to join for each
+// human player before starting.
+func serve(addr string) {
 
-	fid, err := os.Create("mule.log")
+	gameinfo, saved := gameInfo()
+
+	ln := listen(addr)
+	srv := mule.NewServer(ln)
+	srv.RevealSecrets = *reveal
+	defer srv.Close()
+	if *spectate != "" {
+		srv.Spectate(listen(*spectate))
+	}
+
+	var seats []int
+	for p := range gameinfo.PlayerNames {
+		if p >= len(gameinfo.Computer) || !gameinfo.Computer[p] {
+			seats = append(seats, p)
+		}
+	}
+
+	fmt.Printf("Waiting for %d players to join on %s\n", len(seats), ln.Addr())
+	names, err := srv.WaitForPlayers(seats)
 	if err != nil {
 		panic(err)
 	}
-	defer fid.Close()
-	mg.Logger = log.New(fid, "", log.Lshortfile)
 
+	// Players of a new game go by the names they joined with
+	if saved == nil {
+		for k, p := range seats {
+			if names[k] != "" {
+				gameinfo.PlayerNames[p] = names[k]
+			}
+		}
+	}
+
+	mg, done := newGame(gameinfo, saved, srv.Actions())
+	defer done()
+	mg.Renderer = srv
+	srv.Logger = mg.Logger
+
+	fmt.Printf("The game has started\n")
 	mg.Play()
 }
+
+// join plays in a game hosted by another machine.
+func join(addr string) {
+
+	var name string
+	for name == "" {
+		fmt.Print("What is your name? ")
+		fmt.Scanln(&name)
+	}
+
+	conn := dial(addr)
+	defer conn.Close()
+
+	err := termbox.Init()
+	if err != nil {
+		panic(err)
+	}
+	defer termbox.Close()
+
+	acts := make(chan mule.Action)
+	go func() {
+		for {
+			x := termbox.PollEvent()
+			if x.Type == termbox.EventKey && x.Key == termbox.KeyCtrlC {
+				close(acts)
+				return
+			}
+			for _, a := range mule.RemoteActions(x) {
+				acts <- a
+			}
+		}
+	}()
+
+	err = mule.Join(conn, name, mule.TermboxRenderer{}, acts)
+	if err != nil {
+		termbox.Close()
+		panic(err)
+	}
+}
+
+// watch shows a game hosted by another machine, without taking part.
+func watch(addr string) {
+
+	conn := dial(addr)
+	defer conn.Close()
+
+	err := termbox.Init()
+	if err != nil {
+		panic(err)
+	}
+	defer termbox.Close()
+
+	// Keys do nothing but stop watching
+	go func() {
+		for {
+			x := termbox.PollEvent()
+			if x.Type == termbox.EventKey && x.Key == termbox.KeyCtrlC {
+				conn.Close()
+				return
+			}
+		}
+	}()
+
+	err = mule.Watch(conn, mule.TermboxRenderer{})
+	if err != nil {
+		termbox.Close()
+		panic(err)
+	}
+}
+
+// replay plays back a recorded game, feeding it the recorded inputs.
+func replay(fname string) {
+
+	rp, err := mule.LoadReplay(fname)
+	if err != nil {
+		panic(err)
+	}
+
+	// Don't overwrite the recording or the saved game, or the logs
+	// of the game that was recorded, the events are only logged if
+	// asked for
+	*record = ""
+	*save = ""
+	logName = "mule-replay.log"
+	if !flagSet("events") {
+		*events = ""
+	}
+
+	if *speed <= 0 {
+		fmt.Fprintf(os.Stderr, "The replay speed must be positive\n")
+		os.Exit(2)
+	}
+
+	// The replay runs the game's clock, so that it sees the inputs
+	// and the timers in the same order as when it was recorded
+	clock := mule.NewFakeClock(time.Now())
+	rp.GameInfo.Clock = clock
+
+	eventQueue := make(chan mule.Action)
+	mg, done := newGame(rp.GameInfo, rp.Saved, eventQueue)
+	defer done()
+	mg.Logger.Printf("Replaying %s", fname)
+
+	sp := 0.0
+	if !*headless {
+		sp = *speed
+		err = termbox.Init()
+		if err != nil {
+			panic(err)
+		}
+		defer termbox.Close()
+
+		// Keys do nothing but stop the replay
+		go func() {
+			for {
+				x := termbox.PollEvent()
+				if x.Type == termbox.EventKey && x.Key == termbox.KeyCtrlC {
+					termbox.Close()
+					os.Exit(0)
+				}
+			}
+		}()
+		mg.Renderer = mule.TermboxRenderer{}
+	}
+
+	finished, err := mg.Replay(rp, clock, sp)
+	if !*headless {
+		termbox.Close()
+	}
+	switch {
+	case err != nil:
+		mg.Logger.Printf("Replay failed: %v", err)
+		fmt.Fprintf(os.Stderr, "%s: %v\n", fname, err)
+		done()
+		os.Exit(1)
+	case finished:
+		fmt.Printf("Replayed %d inputs from %s\n", len(rp.Inputs), fname)
+	default:
+		fmt.Printf("Replayed %d inputs from %s, the game is waiting for more\n", len(rp.Inputs), fname)
+	}
+}
+
+// flagSet returns true if the flag was given on the command line.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
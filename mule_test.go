@@ -0,0 +1,209 @@
+package mule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestGame returns a beginner game of two human players on a fake
+// clock, drawn to a RecordingRenderer, and the queue that takes their
+// input.
+func newTestGame() (*MULE, *FakeClock, *RecordingRenderer, chan Action) {
+	fc := NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	gi := &GameInfo{
+		PlayerNames:  []string{"ann", "bob"},
+		PlayerColors: DefaultPlayerColors(2),
+		Seed:         1,
+		Level:        LevelBeginner,
+		Clock:        fc,
+	}
+
+	// Unbuffered, so that input is only taken while the game waits for it
+	q := make(chan Action)
+	mg := NewMule(NewModel(gi), NewStoreView(), NewFieldView(), NewAuctionView(), q, gi)
+	rr := NewRecordingRenderer()
+	mg.Renderer = rr
+	return mg, fc, rr, q
+}
+
+// play runs f in its own goroutine and moves the clock on a step at a
+// time until f returns, calling act after every step.
+func play(t *testing.T, fc *FakeClock, step time.Duration, f func(), act func()) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+
+	for k := 0; k < 100000; k++ {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		fc.Advance(step)
+
+		// Give the game a moment to catch up
+		time.Sleep(100 * time.Microsecond)
+		act()
+	}
+	t.Fatal("the game didn't finish")
+}
+
+// press sends a to the game if it is waiting for input, and returns
+// true if it was taken.
+func press(q chan<- Action, a Action) bool {
+	select {
+	case q <- a:
+		return true
+	default:
+		return false
+	}
+}
+
+// banner returns the text of the banner lines.
+func banner(rr *RecordingRenderer) string {
+	return rr.Line(0) + "\n" + rr.Line(1)
+}
+
+func TestPlayerTurn(t *testing.T) {
+
+	mg, fc, rr, q := newTestGame()
+	avail := mg.Model.playerTurnTime(0, 0)
+
+	started, outOfTime := false, false
+	play(t, fc, 50*time.Millisecond, func() { mg.PlayerTurn(0, 0) }, func() {
+		switch {
+		case strings.Contains(strings.ToLower(banner(rr)), "press space to start"):
+			// Space pressed too soon is thrown away, so keep
+			// pressing until the turn starts
+			if press(q, Action{Player: 0, Type: ActionConfirm}) {
+				started = true
+			}
+		case started:
+			// Walk out of the store into the field, where the
+			// wumpus is
+			press(q, Action{Player: 0, Type: ActionMove, Dir: DirLeft})
+		}
+		if strings.Contains(banner(rr), "out of time") {
+			outOfTime = true
+		}
+	})
+
+	if !started {
+		t.Fatal("the turn never started")
+	}
+	if mg.currentStage != stageLiveField {
+		t.Error("the player never left the store")
+	}
+	if !outOfTime {
+		t.Error("the player wasn't told that the time was up")
+	}
+	if mg.timeRemaining >= avail {
+		t.Errorf("%d of %d seconds left at the end of the turn", mg.timeRemaining, avail)
+	}
+
+	// Nothing of the turn is left running
+	if mg.turnTimer != nil {
+		t.Error("the turn timer wasn't stopped")
+	}
+	waitFor(t, "the turn's timers to stop", func() bool { return fc.Waiters() == 0 })
+
+	// Nor does the time of the turn show up later, e.g. over the next
+	// auction
+	for k := 0; k < len("Time: 99s"); k++ {
+		rr.SetCell(timeX0+k, statusbar_y, ' ', ColorWhite, ColorBlack)
+	}
+	for k := 0; k < 5; k++ {
+		fc.Advance(time.Second)
+		time.Sleep(time.Millisecond)
+	}
+	if line := rr.Line(statusbar_y); strings.Contains(line, "Time:") {
+		t.Errorf("the turn's time was shown after the turn: %q", line)
+	}
+}
+
+func TestAuction(t *testing.T) {
+
+	mg, fc, rr, q := newTestGame()
+	md := mg.Model
+	py := md.Players[0]
+	money, store := py.money, md.storeSmithore
+
+	// Nobody has smithore, so both players buy from the store, and
+	// ann bids up as far as its price
+	av := mg.Auctionview
+	av.Init(smithore, 0)
+	play(t, fc, 50*time.Millisecond, func() {
+		if av.DoDeclaration(0) {
+			av.DoAuction(0)
+		}
+	}, func() {
+		switch {
+		case strings.Contains(strings.ToLower(banner(rr)), "press space"):
+			press(q, Action{Player: AnyPlayer, Type: ActionConfirm})
+		case strings.HasPrefix(strings.TrimSpace(rr.Line(0)), "Smithore auction..."):
+			press(q, Action{Player: 0, Type: ActionAuctionUp})
+		}
+	})
+
+	if py.Smithore == 0 {
+		t.Fatal("ann didn't buy any smithore")
+	}
+	if md.storeSmithore+py.Smithore != store {
+		t.Errorf("the store has %d smithore after selling %d of %d", md.storeSmithore, py.Smithore, store)
+	}
+	if py.money >= money {
+		t.Errorf("ann has $%d after buying, and had $%d", py.money, money)
+	}
+	if md.Players[1].Smithore != 0 {
+		t.Error("bob bought smithore without bidding")
+	}
+	waitFor(t, "the auction's timers to stop", func() bool { return fc.Waiters() == 0 })
+}
+
+func TestWaitForSpace(t *testing.T) {
+
+	mg, fc, _, q := newTestGame()
+
+	// Only ann can start ann's turn
+	k, pressed := 0, false
+	play(t, fc, 50*time.Millisecond, func() { mg.WaitForSpace(0) }, func() {
+		k++
+		if k < 20 {
+			press(q, Action{Player: 1, Type: ActionConfirm})
+		} else if press(q, Action{Player: 0, Type: ActionConfirm}) {
+			pressed = true
+		}
+	})
+	if !pressed {
+		t.Error("another player pressed space for ann")
+	}
+}
+
+func TestVotes(t *testing.T) {
+
+	mg, _, _, _ := newTestGame()
+	v := mg.newVotes()
+	if v.add(Action{Player: 0, Type: ActionEndPhase}) {
+		t.Error("one of two players ended the phase")
+	}
+	if v.add(Action{Player: 0, Type: ActionEndPhase}) {
+		t.Error("one player ended the phase by asking twice")
+	}
+	if !v.add(Action{Player: 1, Type: ActionEndPhase}) {
+		t.Error("both players asked and the phase didn't end")
+	}
+
+	// The shared keyboard speaks for everyone, computers don't vote
+	if !mg.newVotes().add(Action{Player: AnyPlayer, Type: ActionEndPhase}) {
+		t.Error("the shared keyboard didn't end the phase")
+	}
+	mg.TurnDrivers[1] = NewComputer()
+	if !mg.newVotes().add(Action{Player: 0, Type: ActionEndPhase}) {
+		t.Error("the only human player didn't end the phase")
+	}
+}
@@ -0,0 +1,246 @@
+package mule
+
+import (
+	"sync"
+	"time"
+)
+
+// pauseClock is a clock that can be stopped.  While it is paused no
+// timers fire and sleeps don't end, and its time stands still, so
+// that everything carries on exactly where it was when it resumes.
+type pauseClock struct {
+	base Clock
+
+	mu       sync.Mutex
+	paused   bool
+	pausedAt time.Time     // base time of the pause
+	lost     time.Duration // base time spent paused before that
+	waiters  []*pauseWaiter
+
+	// Told about the sleeps and the times sent, nil unless the game
+	// is being replayed
+	q *quiet
+}
+
+// pauseWaiter is a timer, ticker or sleep waiting on a pauseClock.
+// While the clock runs, it is waiting on a base clock timer.
+type pauseWaiter struct {
+	pc     *pauseClock
+	when   time.Time // in the pauseClock's time
+	period time.Duration
+	c      chan time.Time
+	f      func()
+	t      Timer
+
+	// Counts the base timers set for w, so that one that goes off
+	// after it was replaced is ignored
+	gen int
+}
+
+func newPauseClock(base Clock) *pauseClock {
+	return &pauseClock{base: base}
+}
+
+// now returns the clock's time, with pc.mu held.
+func (pc *pauseClock) now() time.Time {
+	if pc.paused {
+		return pc.pausedAt.Add(-pc.lost)
+	}
+	return pc.base.Now().Add(-pc.lost)
+}
+
+func (pc *pauseClock) Now() time.Time {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.now()
+}
+
+func (pc *pauseClock) add(d, period time.Duration, f func()) *pauseWaiter {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	w := &pauseWaiter{pc: pc, when: pc.now().Add(d), period: period, f: f}
+	if f == nil {
+		w.c = make(chan time.Time, 1)
+	}
+	pc.waiters = append(pc.waiters, w)
+	if !pc.paused {
+		pc.schedule(w)
+	}
+	return w
+}
+
+// schedule sets a base clock timer for w, with pc.mu held.
+func (pc *pauseClock) schedule(w *pauseWaiter) {
+	w.gen++
+	gen := w.gen
+	w.t = pc.base.AfterFunc(w.when.Sub(pc.now()), func() { pc.fire(w, gen) })
+}
+
+// fire is called when base timer number gen of w goes off.
+func (pc *pauseClock) fire(w *pauseWaiter, gen int) {
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	// The timer may have gone off just as the clock was paused or
+	// w was stopped
+	if pc.paused || w.gen != gen || !pc.has(w) {
+		return
+	}
+
+	// Send the time w was due rather than the time it went off,
+	// which can be a little later
+	due := w.when
+	if w.period > 0 {
+		w.when = w.when.Add(w.period)
+		pc.schedule(w)
+	} else {
+		pc.remove(w)
+	}
+
+	if w.f != nil {
+		go w.f()
+		return
+	}
+	pc.q.sent(w.c)
+	select {
+	case w.c <- due:
+	default:
+		pc.q.unsent(w.c)
+	}
+}
+
+func (pc *pauseClock) has(w *pauseWaiter) bool {
+	for _, x := range pc.waiters {
+		if x == w {
+			return true
+		}
+	}
+	return false
+}
+
+func (pc *pauseClock) remove(w *pauseWaiter) bool {
+	for k, x := range pc.waiters {
+		if x == w {
+			pc.waiters = append(pc.waiters[:k], pc.waiters[k+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (pc *pauseClock) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	w := pc.add(d, 0, nil)
+	pc.q.blocked(w.c)
+	<-w.c
+	pc.q.woke(w.c)
+}
+
+func (pc *pauseClock) NewTimer(d time.Duration) Timer {
+	return pc.add(d, 0, nil)
+}
+
+func (pc *pauseClock) AfterFunc(d time.Duration, f func()) Timer {
+	return pc.add(d, 0, f)
+}
+
+func (pc *pauseClock) NewTicker(d time.Duration) Ticker {
+	return pauseTicker{pc.add(d, d, nil)}
+}
+
+// Pause stops the clock.
+func (pc *pauseClock) Pause() {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.paused {
+		return
+	}
+	pc.pausedAt = pc.base.Now()
+	pc.paused = true
+	for _, w := range pc.waiters {
+		w.t.Stop()
+		w.t = nil
+	}
+}
+
+// Resume restarts the clock from where it was paused.
+func (pc *pauseClock) Resume() {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if !pc.paused {
+		return
+	}
+	pc.lost += pc.base.Now().Sub(pc.pausedAt)
+	pc.paused = false
+	for _, w := range pc.waiters {
+		pc.schedule(w)
+	}
+}
+
+func (w *pauseWaiter) C() <-chan time.Time { return w.c }
+
+func (w *pauseWaiter) Stop() bool {
+	pc := w.pc
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if w.t != nil {
+		w.t.Stop()
+	}
+	return pc.remove(w)
+}
+
+type pauseTicker struct{ w *pauseWaiter }
+
+func (pt pauseTicker) C() <-chan time.Time { return pt.w.c }
+func (pt pauseTicker) Stop()               { pt.w.Stop() }
+
+// pauseMsg is the banner shown while the game is paused, over the time
+// on the status bar line, so that the banners above it and the status
+// bar can stay.  It has to fit between the time and the edge of an 80
+// column screen.
+const pauseMsg = "PAUSED (esc)"
+
+// pauseGame stops the game until a player asks to resume it.  The
+// clock stops, so the time left in a turn or an auction, and the
+// wumpus, stay where they are.  Inputs taken while paused are dropped,
+// and they are left out of a replay along with the pauses, as no game
+// time passes.
+func (mg *MULE) pauseGame() {
+
+	mg.pause.Pause()
+	mg.Logger.Printf("Game paused")
+	mg.pauseLine(pauseMsg)
+
+	for {
+		a := <-mg.input()
+		mg.quiet.woke(mg.eventQueue)
+		mg.record(a, true)
+		if a.Type == ActionPause {
+			break
+		}
+	}
+
+	mg.pauseLine("")
+	mg.Logger.Printf("Game resumed")
+	mg.pause.Resume()
+}
+
+// pauseLine shows msg, or clears the pause banner and puts back the
+// time it covered if msg is empty.
+func (mg *MULE) pauseLine(msg string) {
+	fg := ColorWhite | AttrBold
+	if msg == "" {
+		msg, fg = mg.timeMsg, ColorWhite
+	}
+	for k := 0; k < len(pauseMsg); k++ {
+		c := ' '
+		if k < len(msg) {
+			c = rune(msg[k])
+		}
+		mg.Renderer.SetCell(timeX0+k, statusbar_y, c, fg, ColorBlack)
+	}
+	mg.Renderer.Flush()
+}
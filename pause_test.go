@@ -0,0 +1,135 @@
+package mule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPauseClock(t *testing.T) {
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	fc := NewFakeClock(start)
+	pc := newPauseClock(fc)
+	timer := pc.NewTimer(time.Second)
+	tick := pc.NewTicker(300 * time.Millisecond)
+	defer tick.Stop()
+
+	fired := func(c <-chan time.Time) (time.Time, bool) {
+		select {
+		case tm := <-c:
+			return tm, true
+		default:
+			return time.Time{}, false
+		}
+	}
+
+	fc.Advance(500 * time.Millisecond)
+	if _, ok := fired(tick.C()); !ok {
+		t.Error("the ticker didn't tick before the pause")
+	}
+
+	// Nothing moves while paused
+	pc.Pause()
+	fc.Advance(10 * time.Second)
+	if _, ok := fired(timer.C()); ok {
+		t.Error("the timer went off while paused")
+	}
+	if _, ok := fired(tick.C()); ok {
+		t.Error("the ticker ticked while paused")
+	}
+	if now := pc.Now(); !now.Equal(start.Add(500 * time.Millisecond)) {
+		t.Errorf("the clock moved on to %v while paused", now.Sub(start))
+	}
+
+	// And carries on from where it stopped
+	pc.Resume()
+	fc.Advance(100 * time.Millisecond)
+	if tm, ok := fired(tick.C()); !ok || !tm.Equal(start.Add(600*time.Millisecond)) {
+		t.Errorf("the ticker ticked at %v after the pause", tm.Sub(start))
+	}
+	fc.Advance(399 * time.Millisecond)
+	if _, ok := fired(timer.C()); ok {
+		t.Error("the timer went off early")
+	}
+	fc.Advance(time.Millisecond)
+	if tm, ok := fired(timer.C()); !ok || !tm.Equal(start.Add(time.Second)) {
+		t.Errorf("the timer went off at %v, %v", tm.Sub(start), ok)
+	}
+	if now := pc.Now(); !now.Equal(start.Add(time.Second)) {
+		t.Errorf("the clock is at %v after a second of play", now.Sub(start))
+	}
+
+	// A timer set while paused only starts when resumed
+	pc.Pause()
+	late := pc.NewTimer(100 * time.Millisecond)
+	fc.Advance(time.Second)
+	if _, ok := fired(late.C()); ok {
+		t.Error("a timer set while paused went off")
+	}
+	pc.Resume()
+	fc.Advance(100 * time.Millisecond)
+	if _, ok := fired(late.C()); !ok {
+		t.Error("a timer set while paused didn't go off after the pause")
+	}
+}
+
+func TestPauseWhileSleeping(t *testing.T) {
+
+	mg, fc, rr, q := newTestGame()
+
+	// Pause straight after the sleep starts, resume after 10s
+	k, paused := 0, false
+	start := mg.Clock.Now()
+	play(t, fc, 50*time.Millisecond, func() { mg.sleep(time.Second) }, func() {
+		k++
+		switch {
+		case k == 2:
+			press(q, Action{Player: AnyPlayer, Type: ActionPause})
+		case k > 2 && k < 200:
+			if strings.Contains(rr.Line(statusbar_y), pauseMsg) {
+				paused = true
+			}
+		case k == 200:
+			press(q, Action{Player: AnyPlayer, Type: ActionPause})
+		}
+	})
+
+	if !paused {
+		t.Error("the game didn't pause while sleeping")
+	}
+	if k < 200 {
+		t.Errorf("the sleep ended after %d steps while paused", k)
+	}
+	if d := mg.Clock.Now().Sub(start); d < time.Second || d > 2*time.Second {
+		t.Errorf("a sleep of a second took %v of game time", d)
+	}
+}
+
+func TestDrainKeepsPause(t *testing.T) {
+
+	mg, fc, rr, _ := newTestGame()
+
+	// A pause pressed before a prompt pauses the game rather than
+	// being thrown away with the other inputs
+	q := make(chan Action, 2)
+	mg.eventQueue = q
+	q <- Action{Player: 0, Type: ActionConfirm}
+	q <- Action{Player: AnyPlayer, Type: ActionPause}
+	paused := false
+	play(t, fc, 50*time.Millisecond, mg.drainQueue, func() {
+		if !paused && strings.Contains(rr.Line(statusbar_y), pauseMsg) {
+			paused = true
+			q <- Action{Player: AnyPlayer, Type: ActionPause}
+		}
+	})
+	if !paused {
+		t.Error("the game didn't pause")
+	}
+}
+
+func TestPauseMsgFits(t *testing.T) {
+	if timeX0+len(pauseMsg) > 80 {
+		t.Errorf("the pause banner runs to column %d", timeX0+len(pauseMsg))
+	}
+}
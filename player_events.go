@@ -2,14 +2,11 @@ package mule
 
 // http://bringerp.free.fr/RE/Mule/mule_document.html#RandomPlayerEvent
 
-import (
-	"fmt"
-	"math/rand"
-)
+import "fmt"
 
 func hasMule(p int, mg *MULE) bool {
-	for i := 0; i < nrow; i++ {
-		for j := 0; j < ncol; j++ {
+	for i := 0; i < mg.Model.nrow; i++ {
+		for j := 0; j < mg.Model.ncol; j++ {
 			pl := mg.Model.GetPlot(i, j)
 			if pl.Owned && pl.Owner == p && pl.MuleStatus != outfitNone {
 				return true
@@ -22,8 +19,8 @@ func hasMule(p int, mg *MULE) bool {
 
 func countMiningMules(p int, mg *MULE) int {
 	m := 0
-	for i := 0; i < nrow; i++ {
-		for j := 0; j < ncol; j++ {
+	for i := 0; i < mg.Model.nrow; i++ {
+		for j := 0; j < mg.Model.ncol; j++ {
 			pl := mg.Model.GetPlot(i, j)
 			if pl.Owned && pl.Owner == p && (pl.MuleStatus == outfitSmithore || pl.MuleStatus == outfitCrystite) {
 				m++
@@ -36,8 +33,8 @@ func countMiningMules(p int, mg *MULE) int {
 
 func countEnergyMules(p int, mg *MULE) int {
 	m := 0
-	for i := 0; i < nrow; i++ {
-		for j := 0; j < ncol; j++ {
+	for i := 0; i < mg.Model.nrow; i++ {
+		for j := 0; j < mg.Model.ncol; j++ {
 			pl := mg.Model.GetPlot(i, j)
 			if pl.Owned && pl.Owner == p && pl.MuleStatus == outfitEnergy {
 				m++
@@ -50,8 +47,8 @@ func countEnergyMules(p int, mg *MULE) int {
 
 func loosePlot(p int, mg *MULE) bool {
 	var plts []*Plot
-	for i := 0; i < nrow; i++ {
-		for j := 0; j < ncol; j++ {
+	for i := 0; i < mg.Model.nrow; i++ {
+		for j := 0; j < mg.Model.ncol; j++ {
 			pl := mg.Model.GetPlot(i, j)
 			if pl.Owned && pl.Owner == p {
 				plts = append(plts, pl)
@@ -63,7 +60,7 @@ func loosePlot(p int, mg *MULE) bool {
 		return false
 	}
 
-	k := int(rand.Int63() % int64(len(plts)))
+	k := int(mg.Model.rng.Int63() % int64(len(plts)))
 	plts[k].Owned = false
 	plts[k].MuleStatus = outfitNone
 
@@ -72,8 +69,8 @@ func loosePlot(p int, mg *MULE) bool {
 
 func freePlot(p int, mg *MULE) bool {
 	var plts []*Plot
-	for i := 0; i < nrow; i++ {
-		for j := 0; j < ncol; j++ {
+	for i := 0; i < mg.Model.nrow; i++ {
+		for j := 0; j < mg.Model.ncol; j++ {
 			pl := mg.Model.GetPlot(i, j)
 			if !pl.Owned {
 				plts = append(plts, pl)
@@ -85,7 +82,7 @@ func freePlot(p int, mg *MULE) bool {
 		return false
 	}
 
-	k := int(rand.Int63() % int64(len(plts)))
+	k := int(mg.Model.rng.Int63() % int64(len(plts)))
 	plts[k].Owned = true
 	plts[k].Owner = p
 	return true
@@ -93,8 +90,8 @@ func freePlot(p int, mg *MULE) bool {
 
 func countFood(p int, mg *MULE) int {
 	q := 0
-	for i := 0; i < nrow; i++ {
-		for j := 0; j < ncol; j++ {
+	for i := 0; i < mg.Model.nrow; i++ {
+		for j := 0; j < mg.Model.ncol; j++ {
 			pl := mg.Model.GetPlot(i, j)
 			if pl.Owned && pl.Owner == p && pl.MuleStatus == outfitFood {
 				q++
@@ -109,7 +106,7 @@ func draw(p, r int, mg *MULE) (string, bool) {
 
 	var k int
 	for {
-		k = int(rand.Int63() % int64(22))
+		k = int(mg.Model.rng.Int63() % int64(22))
 		if !mg.playerEventHappened[k] {
 			mg.playerEventHappened[k] = true
 			break
@@ -229,7 +226,7 @@ func draw(p, r int, mg *MULE) (string, bool) {
 
 func (mg *MULE) genEvent(p, r int) string {
 
-	if int(rand.Int63()%int64(100)) >= 28 {
+	if int(mg.Model.rng.Int63()%int64(100)) >= mg.Model.rules.PlayerEventChance {
 		return ""
 	}
 
@@ -0,0 +1,123 @@
+package mule
+
+import (
+	"strings"
+	"sync"
+)
+
+// Attribute is a cell color and style.  The colors and styles share
+// the encoding used by termbox, with the style bits above the color.
+type Attribute uint16
+
+const (
+	ColorDefault Attribute = iota
+	ColorBlack
+	ColorRed
+	ColorGreen
+	ColorYellow
+	ColorBlue
+	ColorMagenta
+	ColorCyan
+	ColorWhite
+)
+
+const (
+	AttrBold Attribute = 1 << (iota + 9)
+	AttrUnderline
+	AttrReverse
+)
+
+// Renderer receives everything the game draws.  The banner, status
+// bar, field, store, auction and leaderboard are all drawn cell by
+// cell through SetCell, and become visible when Flush is called.
+type Renderer interface {
+	SetCell(x, y int, c rune, fg, bg Attribute)
+	Flush()
+}
+
+// SecretRenderer is a Renderer that shows some viewers less than
+// others, e.g. the spectators of a network game.  Cells drawn with
+// SetSecretCell show c to those allowed to see hidden information,
+// such as assay results, and cover to everyone else.
+type SecretRenderer interface {
+	Renderer
+	SetSecretCell(x, y int, c, cover rune, fg, bg Attribute)
+}
+
+// NullRenderer discards all drawing, for running games without a
+// terminal.
+type NullRenderer struct{}
+
+func (NullRenderer) SetCell(x, y int, c rune, fg, bg Attribute) {}
+
+func (NullRenderer) Flush() {}
+
+// Cell is the content of one screen position.
+type Cell struct {
+	Ch rune
+	Fg Attribute
+	Bg Attribute
+}
+
+// RecordingRenderer keeps the most recently drawn content of every
+// screen position, so that tests and servers can inspect the board.
+type RecordingRenderer struct {
+	mu      sync.Mutex
+	cells   map[[2]int]Cell
+	flushes int
+}
+
+func NewRecordingRenderer() *RecordingRenderer {
+	rr := new(RecordingRenderer)
+	rr.cells = make(map[[2]int]Cell)
+	return rr
+}
+
+func (rr *RecordingRenderer) SetCell(x, y int, c rune, fg, bg Attribute) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	rr.cells[[2]int{x, y}] = Cell{c, fg, bg}
+}
+
+func (rr *RecordingRenderer) Flush() {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	rr.flushes++
+}
+
+// Flushes returns the number of times that Flush has been called.
+func (rr *RecordingRenderer) Flushes() int {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	return rr.flushes
+}
+
+// Cell returns the content at position (x, y).
+func (rr *RecordingRenderer) Cell(x, y int) Cell {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	return rr.cells[[2]int{x, y}]
+}
+
+// Line returns the characters in row y from column 0 up to the last
+// column that has been drawn, with trailing blanks removed.
+func (rr *RecordingRenderer) Line(y int) string {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	xmax := -1
+	for k := range rr.cells {
+		if k[1] == y && k[0] > xmax {
+			xmax = k[0]
+		}
+	}
+
+	b := make([]rune, xmax+1)
+	for x := range b {
+		b[x] = ' '
+		if c, ok := rr.cells[[2]int{x, y}]; ok && c.Ch != 0 {
+			b[x] = c.Ch
+		}
+	}
+	return strings.TrimRight(string(b), " ")
+}
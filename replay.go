@@ -0,0 +1,410 @@
+package mule
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Version of the replay file format, increment when the format
+// changes
+const replayVersion = 4
+
+// A replay file starts with a replayHeader line, followed by one
+// RecordedAction line for each input, all in JSON.
+type replayHeader struct {
+	Version int
+
+	// The game setup, including the random seed, and the saved game
+	// if the game was resumed
+	GameInfo *GameInfo
+	Saved    *SavedGame
+}
+
+// RecordedAction is an input as it was taken by the game.  Phases are
+// counted from the start of the game, each time the game starts
+// waiting for input, e.g. a player's turn, an auction or a message
+// that needs space to be pressed.  Within a phase, the input is placed
+// by the number of clock events that the game saw before it, which
+// decides what the game does with it, and by its time, which decides
+// the rest.
+type RecordedAction struct {
+	Phase  int
+	Tick   int64         // clock events seen since the start of the phase
+	Offset time.Duration // since the start of the phase
+	Action Action
+
+	// The game threw the input away, or it paused or resumed the
+	// game, so it doesn't need to be replayed
+	Ignored bool `json:",omitempty"`
+}
+
+// Replay is a recorded game.
+type Replay struct {
+	GameInfo *GameInfo
+	Saved    *SavedGame
+	Inputs   []RecordedAction
+}
+
+// phaseState is the phase the game is in, shared with the goroutine
+// replaying a game.
+type phaseState struct {
+	sync.Mutex
+	n     int
+	start time.Time
+	ticks int64
+}
+
+// startPhase is called each time the game starts waiting for input.
+func (mg *MULE) startPhase() {
+	ps := &mg.phase
+	ps.Lock()
+	defer ps.Unlock()
+	ps.n++
+	ps.start = mg.Clock.Now()
+	ps.ticks = 0
+}
+
+// currentPhase returns the phase, and the time and the number of
+// clock events seen since it started.
+func (mg *MULE) currentPhase() (int, time.Duration, int64) {
+	ps := &mg.phase
+	ps.Lock()
+	defer ps.Unlock()
+	return ps.n, mg.Clock.Now().Sub(ps.start), ps.ticks
+}
+
+// input returns the input queue for the game to wait on along with
+// clock, the channels of its timers and tickers.  If one of them is
+// ready, the queue returned is nil, so that the game always sees the
+// clock events that are due before an input that came after them, and
+// a replay gives the same order.
+func (mg *MULE) input(clock ...<-chan time.Time) <-chan Action {
+	var q <-chan Action = mg.eventQueue
+	chs := []interface{}{mg.eventQueue}
+	for _, c := range clock {
+		if len(c) > 0 {
+			q = nil
+		}
+		chs = append(chs, c)
+	}
+	mg.quiet.blocked(chs...)
+	return q
+}
+
+// woke is called when the game, waiting for input, wakes up on c
+// instead.  It is counted as a clock event of the phase.
+func (mg *MULE) woke(c <-chan time.Time) {
+	mg.quiet.woke(c)
+	ps := &mg.phase
+	ps.Lock()
+	defer ps.Unlock()
+	ps.ticks++
+}
+
+// Record writes every input that the game takes to w, so that the
+// game can be replayed.  gi and saved are how the game was set up.
+// It must be called before Play.
+func (mg *MULE) Record(w io.Writer, gi *GameInfo, saved *SavedGame) error {
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(&replayHeader{Version: replayVersion, GameInfo: gi, Saved: saved}); err != nil {
+		return err
+	}
+	mg.recorder = enc
+	return nil
+}
+
+// took is called with each input that the game takes from its input
+// queue.  It records the input and, if it asks for a pause, returns
+// when the game is resumed.
+func (mg *MULE) took(a Action) {
+	mg.quiet.woke(mg.eventQueue)
+	if a.Type == ActionPause {
+		mg.record(a, true)
+		mg.pauseGame()
+		return
+	}
+	mg.record(a, false)
+}
+
+// record writes an input to the replay, if the game is being recorded.
+// ignored is true if the game doesn't act on the input.
+func (mg *MULE) record(a Action, ignored bool) {
+	if mg.recorder == nil {
+		return
+	}
+	n, offset, tick := mg.currentPhase()
+	ra := RecordedAction{Phase: n, Tick: tick, Offset: offset, Action: a, Ignored: ignored}
+	if err := mg.recorder.Encode(&ra); err != nil {
+		mg.Logger.Printf("Unable to record input: %v", err)
+	}
+}
+
+// LoadReplay reads a replay file.
+func LoadReplay(fname string) (*Replay, error) {
+
+	fid, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer fid.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(fid))
+	var hdr replayHeader
+	if err := dec.Decode(&hdr); err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+	if hdr.Version != replayVersion {
+		return nil, fmt.Errorf("%s: unsupported replay file version %d", fname, hdr.Version)
+	}
+	if hdr.GameInfo == nil {
+		return nil, fmt.Errorf("%s: no game in replay file", fname)
+	}
+
+	rp := &Replay{GameInfo: hdr.GameInfo, Saved: hdr.Saved}
+	for {
+		var ra RecordedAction
+		err := dec.Decode(&ra)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// A game that was killed may leave a partial line
+			if err == io.ErrUnexpectedEOF {
+				break
+			}
+			return nil, fmt.Errorf("%s: %v", fname, err)
+		}
+		rp.Inputs = append(rp.Inputs, ra)
+	}
+
+	return rp, nil
+}
+
+// Replay plays the game recorded in rp.  The game must have been set up
+// from rp, on fc, a fake clock that only Replay moves on.  Replay runs
+// the game, steps the clock from one timer to the next, and gives the
+// game each input in the phase and after the clock event in which it
+// was taken when the game was recorded, at the same time, so that the
+// game plays out the same way.  Game time goes by at speed times real
+// time, or as fast as possible if speed is 0.
+//
+// Replay returns true if the game finished, or false if it was left
+// waiting for input, and an error if the game got to an input sooner
+// or later than it did when it was recorded.
+func (mg *MULE) Replay(rp *Replay, fc *FakeClock, speed float64) (bool, error) {
+
+	q := newQuiet()
+	mg.quiet = q
+	mg.pause.q = q
+	go func() {
+		defer q.finish()
+		mg.Play()
+	}()
+
+	for _, ra := range rp.Inputs {
+		if ra.Ignored {
+			continue
+		}
+		for {
+			if q.waitIdle() {
+				return true, fmt.Errorf("the game finished before input %+v for phase %d tick %d",
+					ra.Action, ra.Phase, ra.Tick)
+			}
+
+			n, offset, tick := mg.currentPhase()
+			if n > ra.Phase || n == ra.Phase && tick > ra.Tick {
+				return false, fmt.Errorf("input %+v for phase %d tick %d is late, the game is in phase %d tick %d",
+					ra.Action, ra.Phase, ra.Tick, n, tick)
+			}
+			// The game may have to sleep before it takes input
+			if n < ra.Phase || tick < ra.Tick || !q.waitingFor(mg.eventQueue) {
+				if !mg.step(fc, speed) {
+					return false, fmt.Errorf("input %+v for phase %d tick %d is early, the game is waiting for input in phase %d tick %d",
+						ra.Action, ra.Phase, ra.Tick, n, tick)
+				}
+				continue
+			}
+
+			if d := ra.Offset - offset; d > 0 {
+				pace(d, speed)
+				fc.set(fc.Now().Add(d))
+			}
+			q.sent(mg.eventQueue)
+			mg.eventQueue <- ra.Action
+			break
+		}
+	}
+
+	// Play out what is left on the clock
+	for {
+		if q.waitIdle() {
+			return true, nil
+		}
+		if !mg.step(fc, speed) {
+			return false, nil
+		}
+	}
+}
+
+// step moves fc on to the next timer, ticker or sleep and fires it,
+// returning false if nothing is waiting.
+func (mg *MULE) step(fc *FakeClock, speed float64) bool {
+	next, ok := fc.next()
+	if !ok {
+		return false
+	}
+	pace(next.Sub(fc.Now()), speed)
+	return fc.fireNext()
+}
+
+// pace waits for the real time that d of game time takes at speed.
+func pace(d time.Duration, speed float64) {
+	if speed > 0 && d > 0 {
+		time.Sleep(time.Duration(float64(d) / speed))
+	}
+}
+
+// quiet keeps track of what the game is blocked on, so that a replay
+// can tell when the game has done all it can until the clock moves on
+// or it gets input.  The game runs in a single goroutine, which tells
+// quiet what it waits for and what woke it up, and the clock and the
+// replay tell it what they send.  All of its methods do nothing on a
+// nil quiet, as the game is when it isn't replayed.
+type quiet struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	// What the game is blocked on, nil while it runs
+	chs []interface{}
+
+	// The values sent on each channel and not yet taken, with the
+	// channel itself, which stays in use while it is here
+	pending map[uintptr]*pendingSend
+
+	done bool
+}
+
+type pendingSend struct {
+	ch interface{}
+	n  int
+}
+
+func newQuiet() *quiet {
+	q := &quiet{pending: make(map[uintptr]*pendingSend)}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// chanID identifies a channel whatever its direction.
+func chanID(ch interface{}) uintptr {
+	return reflect.ValueOf(ch).Pointer()
+}
+
+// blocked is called by the game before it waits on chs.
+func (q *quiet) blocked(chs ...interface{}) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.chs = chs
+	q.cond.Broadcast()
+}
+
+// woke is called by the game when it has taken a value from ch.
+func (q *quiet) woke(ch interface{}) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.chs = nil
+	q.take(ch)
+}
+
+// sent is called before a value is sent on ch for the game.
+func (q *quiet) sent(ch interface{}) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	id := chanID(ch)
+	ps := q.pending[id]
+	if ps == nil {
+		ps = &pendingSend{ch: ch}
+		q.pending[id] = ps
+	}
+	ps.n++
+}
+
+// unsent takes back a call to sent when the value was dropped.
+func (q *quiet) unsent(ch interface{}) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.take(ch)
+	q.cond.Broadcast()
+}
+
+// take counts a value sent on ch as gone, with q.mu held.
+func (q *quiet) take(ch interface{}) {
+	id := chanID(ch)
+	if ps := q.pending[id]; ps != nil {
+		if ps.n--; ps.n == 0 {
+			delete(q.pending, id)
+		}
+	}
+}
+
+// finish is called when the game is over.
+func (q *quiet) finish() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.done = true
+	q.cond.Broadcast()
+}
+
+// waitIdle waits until the game is over, returning true, or is blocked
+// with nothing sent that it waits for.
+func (q *quiet) waitIdle() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for !q.done && !q.idle() {
+		q.cond.Wait()
+	}
+	return q.done
+}
+
+// idle returns true if the game is blocked, with q.mu held.
+func (q *quiet) idle() bool {
+	if q.chs == nil {
+		return false
+	}
+	for _, ch := range q.chs {
+		if q.pending[chanID(ch)] != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// waitingFor returns true if the game is blocked on ch.
+func (q *quiet) waitingFor(ch interface{}) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, c := range q.chs {
+		if chanID(c) == chanID(ch) {
+			return true
+		}
+	}
+	return false
+}
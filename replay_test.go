@@ -0,0 +1,78 @@
+package mule
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+
+	// ann is played by random key presses, bob by the computer
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	fc := NewFakeClock(start)
+	gi := &GameInfo{
+		PlayerNames:  []string{"ann", "bob"},
+		PlayerColors: DefaultPlayerColors(2),
+		Computer:     []bool{false, true},
+		Seed:         1,
+		Level:        LevelBeginner,
+		Clock:        fc,
+	}
+	q := make(chan Action)
+	mg := NewMule(NewModel(gi), NewStoreView(), NewFieldView(), NewAuctionView(), q, gi)
+	var events, rec bytes.Buffer
+	mg.Events = &events
+	if err := mg.Record(&rec, gi, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []Action{
+		{Type: ActionConfirm}, {Type: ActionClaimPlot}, {Type: ActionAssay},
+		{Type: ActionMove, Dir: DirLeft}, {Type: ActionMove, Dir: DirRight},
+		{Type: ActionMove, Dir: DirUp}, {Type: ActionMove, Dir: DirDown},
+		{Type: ActionAuctionUp}, {Type: ActionAuctionDown}, {Type: ActionEndPhase},
+		{Type: ActionPause},
+	}
+	rng := rand.New(rand.NewSource(1))
+	play(t, fc, 50*time.Millisecond, mg.Play, func() {
+		a := keys[rng.Intn(len(keys))]
+		if a.Type == ActionPause && rng.Intn(100) > 0 {
+			return
+		}
+		press(q, a)
+	})
+
+	fname := filepath.Join(t.TempDir(), "mule.replay")
+	if err := os.WriteFile(fname, rec.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rp, err := LoadReplay(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rp.Inputs) == 0 {
+		t.Fatal("no inputs were recorded")
+	}
+
+	// The replay plays the same game
+	fr := NewFakeClock(start)
+	rp.GameInfo.Clock = fr
+	rp.GameInfo.PlayerColors = DefaultPlayerColors(2)
+	mr := NewMule(NewModel(rp.GameInfo), NewStoreView(), NewFieldView(), NewAuctionView(), make(chan Action), rp.GameInfo)
+	var replayed bytes.Buffer
+	mr.Events = &replayed
+	finished, err := mr.Replay(rp, fr, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !finished {
+		t.Error("the replayed game didn't finish")
+	}
+	if !bytes.Equal(events.Bytes(), replayed.Bytes()) {
+		t.Errorf("the replay logged other events:\n%s\nthan the game:\n%s", replayed.Bytes(), events.Bytes())
+	}
+}
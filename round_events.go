@@ -2,10 +2,7 @@ package mule
 
 import (
 	"fmt"
-	"math/rand"
 	"time"
-
-	"github.com/nsf/termbox-go"
 )
 
 type roundEventType int
@@ -21,23 +18,16 @@ const (
 	fireInStoreEvent
 )
 
-var (
-	roundEventMax = []int{3, 2, 3, 3, 3, 2, 2, 2}
-)
-
 func (mg *MULE) doSunspots() (string, bool) {
 
-	if mg.roundEventCounts[sunspotsEvent] >= roundEventMax[sunspotsEvent] {
+	if mg.roundEventCounts[sunspotsEvent] >= mg.Model.rules.RoundEventMax[sunspotsEvent] {
 		return "", false
 	}
 	mg.roundEventCounts[sunspotsEvent]++
 
 	for _, plt := range mg.Model.plots {
 		if plt.Owned && plt.MuleStatus == outfitEnergy {
-			plt.Production += 3
-			if plt.Production > 8 {
-				plt.Production = 8
-			}
+			plt.setProduction(plt.Production + 3)
 		}
 	}
 
@@ -47,16 +37,16 @@ func (mg *MULE) doSunspots() (string, bool) {
 
 func (mg *MULE) doAcidRain() (string, bool) {
 
-	if mg.roundEventCounts[acidRainEvent] >= roundEventMax[acidRainEvent] {
+	if mg.roundEventCounts[acidRainEvent] >= mg.Model.rules.RoundEventMax[acidRainEvent] {
 		return "", false
 	}
 	mg.roundEventCounts[acidRainEvent]++
 
-	row := int(rand.Int63() % int64(nrow))
+	row := int(mg.Model.rng.Int63() % int64(mg.Model.nrow))
 
-	for i := 0; i < nrow; i++ {
-		for j := 0; j < ncol; j++ {
-			if i == nrow/2 && j == ncol/2 {
+	for i := 0; i < mg.Model.nrow; i++ {
+		for j := 0; j < mg.Model.ncol; j++ {
+			if mg.Model.isStore(i, j) {
 				continue
 			}
 			plt := mg.Model.GetPlot(i, j)
@@ -76,12 +66,7 @@ func (mg *MULE) doAcidRain() (string, bool) {
 					plt.Production -= 1
 				}
 			}
-			if plt.Production > 8 {
-				plt.Production = 8
-			}
-			if plt.Production < 0 {
-				plt.Production = 0
-			}
+			plt.setProduction(plt.Production)
 		}
 	}
 
@@ -94,30 +79,30 @@ func (mg *MULE) doAcidRain() (string, bool) {
 
 func (mg *MULE) doPlanetquake() (string, bool) {
 
-	if mg.roundEventCounts[planetquakeEvent] >= roundEventMax[planetquakeEvent] {
+	if mg.roundEventCounts[planetquakeEvent] >= mg.Model.rules.RoundEventMax[planetquakeEvent] {
 		return "", false
 	}
 	mg.roundEventCounts[planetquakeEvent]++
 
-	m := nrow * ncol
+	m := mg.Model.nrow * mg.Model.ncol
 	for {
 		// Find a random mountain
-		k := int(rand.Int63() % int64(m))
+		k := int(mg.Model.rng.Int63() % int64(m))
 		if mg.Model.plots[k].Mountains == 0 {
 			continue
 		}
-		j := k % ncol
-		i := k / ncol
+		j := k % mg.Model.ncol
+		i := k / mg.Model.ncol
 
 		// Move the mountains to a neighboring plot
 		for {
-			i1 := 2*int(rand.Int63()%2) - 1
-			j1 := 2*int(rand.Int63()%2) - 1
+			i1 := 2*int(mg.Model.rng.Int63()%2) - 1
+			j1 := 2*int(mg.Model.rng.Int63()%2) - 1
 
-			if i+i1 < 0 || i+i1 >= nrow {
+			if i+i1 < 0 || i+i1 >= mg.Model.nrow {
 				continue
 			}
-			if j+j1 < 0 || j+j1 >= ncol {
+			if j+j1 < 0 || j+j1 >= mg.Model.ncol {
 				continue
 			}
 
@@ -134,7 +119,7 @@ func (mg *MULE) doPlanetquake() (string, bool) {
 
 func (mg *MULE) doPirateShip() (string, bool) {
 
-	if mg.roundEventCounts[pirateShipEvent] >= roundEventMax[pirateShipEvent] {
+	if mg.roundEventCounts[pirateShipEvent] >= mg.Model.rules.RoundEventMax[pirateShipEvent] {
 		return "", false
 	}
 	mg.roundEventCounts[pirateShipEvent]++
@@ -143,13 +128,22 @@ func (mg *MULE) doPirateShip() (string, bool) {
 		mg.Model.Players[p].Crystite = 0
 	}
 
+	if mg.Model.level.pirateLosses() {
+		mg.Model.storeCrystite = 0
+		for p := 0; p < mg.nplayers; p++ {
+			mg.Model.Players[p].Smithore /= 2
+		}
+		msg := "Pirate ship! All crystite and half the smithore in the colony is lost!"
+		return msg, true
+	}
+
 	msg := "Pirate ship! All crystite in the colony is lost!"
 	return msg, true
 }
 
 func (mg *MULE) doFireInStore() (string, bool) {
 
-	if mg.roundEventCounts[fireInStoreEvent] >= roundEventMax[fireInStoreEvent] {
+	if mg.roundEventCounts[fireInStoreEvent] >= mg.Model.rules.RoundEventMax[fireInStoreEvent] {
 		return "", false
 	}
 	mg.roundEventCounts[fireInStoreEvent]++
@@ -176,16 +170,16 @@ func (mg *MULE) doPestAttack() (string, bool) {
 		return "", false
 	}
 
-	k := int(rand.Int63() % int64(len(plv)))
+	k := int(mg.Model.rng.Int63() % int64(len(plv)))
 	plt := plv[k]
-	plt.Production = 0
+	plt.setProduction(0)
 	msg := fmt.Sprintf("Pest attack! %s lost all production from one food plot", mg.PlayerNames[plt.Owner])
 	return msg, true
 }
 
 func (mg *MULE) doRadiation() (string, bool) {
 
-	if mg.roundEventCounts[radiationEvent] >= roundEventMax[radiationEvent] {
+	if mg.roundEventCounts[radiationEvent] >= mg.Model.rules.RoundEventMax[radiationEvent] {
 		return "", false
 	}
 	mg.roundEventCounts[radiationEvent]++
@@ -210,12 +204,12 @@ func (mg *MULE) doRadiation() (string, bool) {
 
 	// Hold the message, then remove the plot
 	msg := fmt.Sprintf("Radiation! %s lost a plot!", mg.PlayerNames[plt.Owner])
-	time.Sleep(2 * time.Second)
+	mg.sleep(2 * time.Second)
 	plt.Owned = false
 	plt.MuleStatus = outfitNone
 	mg.Fieldview.DrawOwnedPlots()
-	termbox.Flush()
-	time.Sleep(2 * time.Second)
+	mg.Renderer.Flush()
+	mg.sleep(2 * time.Second)
 
 	return msg, true
 }
@@ -223,13 +217,13 @@ func (mg *MULE) doRadiation() (string, bool) {
 func (mg *MULE) doMeteorite() (string, bool) {
 
 	for {
-		i := int(rand.Int63() % int64(nrow))
-		j := int(rand.Int63() % int64(ncol))
-		if j == ncol/2 {
+		i := int(mg.Model.rng.Int63() % int64(mg.Model.nrow))
+		j := int(mg.Model.rng.Int63() % int64(mg.Model.ncol))
+		plt := mg.Model.GetPlot(i, j)
+		if plt.River || mg.Model.isStore(i, j) {
 			continue
 		}
-		plt := mg.Model.GetPlot(i, j)
-		plt.Production = 0
+		plt.setProduction(0)
 		plt.MuleStatus = outfitNone
 		plt.Crystite = 4
 		mg.Banner("A meteorite strike creates new crystite deposit!", 0)
@@ -244,16 +238,18 @@ func (mg *MULE) doMeteorite() (string, bool) {
 
 func (mg *MULE) DoRoundEvent(r int) {
 
-	if r == 11 {
+	if r == mg.Model.level.rounds()-1 {
 		mg.Banner("The ship has returned", 0)
-		termbox.Flush()
+		mg.Renderer.Flush()
 		return
 	}
 
-	k := int(rand.Int63() % 20)
+	// Draw again until an event can happen, e.g. a pest attack
+	// needs a food plot owned by one of the leaders
 	var msg string
 	var f bool
 	for {
+		k := int(mg.Model.rng.Int63() % 20)
 		switch {
 		case k <= 3:
 			msg, f = mg.doPestAttack()
@@ -278,12 +274,13 @@ func (mg *MULE) DoRoundEvent(r int) {
 	}
 
 	mg.Fieldview.ShowProduction()
-	termbox.Flush()
+	mg.Renderer.Flush()
 	if len(msg) > 0 {
+		mg.logEvent(EventRound, -1, "message", msg)
 		mg.Banner(msg, 0)
 		mg.Banner("", 1)
-		time.Sleep(5 * time.Second)
+		mg.sleep(5 * time.Second)
 	}
 	mg.Banner("Press space bar to continue", 1)
-	mg.WaitForSpace()
+	mg.WaitForSpace(AnyPlayer)
 }
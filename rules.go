@@ -0,0 +1,158 @@
+package mule
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// Rules holds the numbers that drive the colony's economy, so that
+// house rules can be played without recompiling.
+type Rules struct {
+	// Cost of outfitting a MULE for each good
+	FoodOutfitCost     int
+	EnergyOutfitCost   int
+	SmithoreOutfitCost int
+	CrystiteOutfitCost int
+
+	// What each player starts with
+	StartMoney  int
+	StartFood   int
+	StartEnergy int
+
+	// What the store starts with
+	StoreMules     int
+	StoreMulePrice int
+	StoreFood      int
+	StoreEnergy    int
+	StoreSmithore  int
+	StoreCrystite  int
+
+	// The store builds MULEs from smithore up to this many
+	MaxStoreMules    int
+	SmithorePerMule  int
+	MulePriceFactor  int // MULE price as a multiple of the smithore price
+	ShortageMules    int // fewer MULE equivalents in store raise the smithore price
+	ShortagePrice    int // smithore price rise per MULE equivalent short
+	SmithorePrice    int
+	FoodPrice        int // food price when there is enough food
+	EnergyPrice      int // energy price when there is enough energy
+	CrystiteMinPrice int
+	CrystiteRange    int // crystite price varies randomly above the minimum by up to this
+
+	// The smithore price moves each round by one of SmithoreSteps,
+	// picked with the matching chance out of the sum of
+	// SmithoreStepWeights
+	SmithoreSteps       []int
+	SmithoreStepWeights []int
+
+	// Number of times each round event can happen in a game,
+	// indexed by event type
+	RoundEventMax []int
+
+	// Percent chance of a player event at the start of a turn
+	PlayerEventChance int
+
+	// The wumpus hides for WumpusHideMin to WumpusHideMax seconds,
+	// then shows itself for WumpusShowMin to WumpusShowMax seconds
+	WumpusHideMin int
+	WumpusHideMax int
+	WumpusShowMin int
+	WumpusShowMax int
+
+	// Money for catching the wumpus in the first round, rising by
+	// WumpusRewardStep each round
+	WumpusReward     int
+	WumpusRewardStep int
+}
+
+// DefaultRules returns the rules of the original game.
+func DefaultRules() *Rules {
+	return &Rules{
+		FoodOutfitCost:     25,
+		EnergyOutfitCost:   50,
+		SmithoreOutfitCost: 75,
+		CrystiteOutfitCost: 100,
+
+		StartMoney:  1000,
+		StartFood:   4,
+		StartEnergy: 2,
+
+		StoreMules:     14,
+		StoreMulePrice: 100,
+		StoreFood:      8,
+		StoreEnergy:    8,
+		StoreSmithore:  8,
+		StoreCrystite:  0,
+
+		MaxStoreMules:    14,
+		SmithorePerMule:  2,
+		MulePriceFactor:  2,
+		ShortageMules:    5,
+		ShortagePrice:    100,
+		SmithorePrice:    50,
+		FoodPrice:        30,
+		EnergyPrice:      25,
+		CrystiteMinPrice: 50,
+		CrystiteRange:    100,
+
+		SmithoreSteps:       []int{-14, -7, 0, 7, 14},
+		SmithoreStepWeights: []int{6, 24, 40, 24, 6},
+
+		RoundEventMax: []int{3, 2, 3, 3, 3, 2, 2, 2},
+
+		PlayerEventChance: 28,
+
+		WumpusHideMin: 2,
+		WumpusHideMax: 11,
+		WumpusShowMin: 5,
+		WumpusShowMax: 24,
+
+		WumpusReward:     100,
+		WumpusRewardStep: 25,
+	}
+}
+
+// LoadRules reads rules from a JSON file.  Rules missing from the file
+// keep their default values.
+func LoadRules(fname string) (*Rules, error) {
+
+	b, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	rl := DefaultRules()
+	if err := json.Unmarshal(b, rl); err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+
+	if len(rl.RoundEventMax) != fireInStoreEvent+1 {
+		return nil, fmt.Errorf("%s: RoundEventMax needs %d values", fname, fireInStoreEvent+1)
+	}
+	if rl.SmithorePerMule <= 0 || rl.CrystiteRange <= 0 {
+		return nil, fmt.Errorf("%s: SmithorePerMule and CrystiteRange must be positive", fname)
+	}
+	if len(rl.SmithoreSteps) == 0 || len(rl.SmithoreStepWeights) != len(rl.SmithoreSteps) {
+		return nil, fmt.Errorf("%s: SmithoreStepWeights needs a weight for each of SmithoreSteps", fname)
+	}
+	tot := 0
+	for _, w := range rl.SmithoreStepWeights {
+		if w < 0 {
+			return nil, fmt.Errorf("%s: SmithoreStepWeights can't be negative", fname)
+		}
+		tot += w
+	}
+	if tot == 0 {
+		return nil, fmt.Errorf("%s: SmithoreStepWeights can't all be zero", fname)
+	}
+	if rl.PlayerEventChance < 0 || rl.PlayerEventChance > 100 {
+		return nil, fmt.Errorf("%s: PlayerEventChance must be a percentage", fname)
+	}
+	if rl.WumpusHideMin < 0 || rl.WumpusHideMax < rl.WumpusHideMin ||
+		rl.WumpusShowMin < 0 || rl.WumpusShowMax < rl.WumpusShowMin {
+		return nil, fmt.Errorf("%s: the wumpus times must be ranges of seconds", fname)
+	}
+
+	return rl, nil
+}
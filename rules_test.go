@@ -0,0 +1,78 @@
+package mule
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadRules(t *testing.T) {
+
+	tests := []struct {
+		name string
+		json string
+		ok   bool
+	}{
+		{"empty", `{}`, true},
+		{"house rules", `{"StartMoney": 2000, "SmithoreSteps": [-10, 10], "SmithoreStepWeights": [1, 3]}`, true},
+		{"bad json", `{"StartMoney": }`, false},
+		{"wrong type", `{"StartMoney": "lots"}`, false},
+		{"short RoundEventMax", `{"RoundEventMax": [1, 2]}`, false},
+		{"no smithore per mule", `{"SmithorePerMule": 0}`, false},
+		{"no crystite range", `{"CrystiteRange": -1}`, false},
+		{"unweighted step", `{"SmithoreSteps": [-7, 0, 7], "SmithoreStepWeights": [1, 1]}`, false},
+		{"no steps", `{"SmithoreSteps": [], "SmithoreStepWeights": []}`, false},
+		{"negative weight", `{"SmithoreStepWeights": [6, 24, 40, 24, -6]}`, false},
+		{"zero weights", `{"SmithoreStepWeights": [0, 0, 0, 0, 0]}`, false},
+		{"event chance", `{"PlayerEventChance": 101}`, false},
+		{"wumpus hides backwards", `{"WumpusHideMin": 5, "WumpusHideMax": 4}`, false},
+		{"wumpus shows for no time", `{"WumpusShowMin": -1}`, false},
+	}
+
+	dir := t.TempDir()
+	for _, tc := range tests {
+		fname := filepath.Join(dir, "rules.json")
+		if err := ioutil.WriteFile(fname, []byte(tc.json), 0644); err != nil {
+			t.Fatal(err)
+		}
+		rl, err := LoadRules(fname)
+		if tc.ok && err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+		if !tc.ok && err == nil {
+			t.Errorf("%s: loaded bad rules", tc.name)
+		}
+		if tc.ok && rl.FoodPrice != DefaultRules().FoodPrice {
+			t.Errorf("%s: lost the default food price", tc.name)
+		}
+	}
+
+	if _, err := LoadRules(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("loaded rules from a missing file")
+	}
+}
+
+func TestSmithoreStep(t *testing.T) {
+
+	mg, _, _, _ := newTestGame()
+	md := mg.Model
+	md.rules.SmithoreStepWeights = []int{0, 0, 0, 1, 0}
+	for k := 0; k < 100; k++ {
+		if d := md.smithoreStep(); d != 7 {
+			t.Fatalf("the smithore price moved by %d with only +7 weighted", d)
+		}
+	}
+
+	// The default weights give each step its share
+	md.rules = DefaultRules()
+	cnt := make(map[int]int)
+	for k := 0; k < 10000; k++ {
+		cnt[md.smithoreStep()]++
+	}
+	for k, d := range md.rules.SmithoreSteps {
+		want := 100 * md.rules.SmithoreStepWeights[k]
+		if cnt[d] < want*8/10 || cnt[d] > want*12/10 {
+			t.Errorf("a step of %d came up %d times in 10000, want about %d", d, cnt[d], want)
+		}
+	}
+}
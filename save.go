@@ -0,0 +1,260 @@
+package mule
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+)
+
+// Version of the save file format, increment when the format changes
+const saveVersion = 1
+
+// countingSource is a random source that counts the values drawn from
+// it, so that a generator can be put back in the same state by
+// drawing the same number of values from the same seed.
+type countingSource struct {
+	src rand.Source
+	n   uint64
+}
+
+func newCountingSource(seed int64) *countingSource {
+	return &countingSource{src: rand.NewSource(seed)}
+}
+
+func (cs *countingSource) Int63() int64 {
+	cs.n++
+	return cs.src.Int63()
+}
+
+func (cs *countingSource) Seed(seed int64) {
+	cs.src.Seed(seed)
+	cs.n = 0
+}
+
+type savedPlayer struct {
+	Money         int
+	Food          int
+	Energy        int
+	Smithore      int
+	Crystite      int
+	FoodDeficit   int
+	EnergyDeficit int
+	Score         int
+	Rank          int
+}
+
+type savedStore struct {
+	MulePrice     int
+	FoodPrice     int
+	EnergyPrice   int
+	SmithorePrice int
+	CrystitePrice int
+	Food          int
+	Energy        int
+	Smithore      int
+	Crystite      int
+	Mules         int
+}
+
+// SavedGame is the state of a game between two rounds.
+type SavedGame struct {
+	Version int
+
+	PlayerNames []string
+	Computer    []bool
+	Species     []Species
+	Level       Level
+	Rules       *Rules
+
+	// The random number generator state
+	Seed  int64
+	Draws uint64
+
+	// The next round to be played
+	Round int
+
+	// The field, with Rows*Cols plots stored row-wise
+	Rows     int
+	Cols     int
+	StoreRow int
+	StoreCol int
+	Plots    []*Plot
+
+	Players []savedPlayer
+	Store   savedStore
+
+	PlayerEventHappened map[int]bool
+	RoundEventCounts    []int
+	StarvedRounds       int
+}
+
+// GameInfo returns the information needed to set up the model and
+// views for a saved game, before calling Restore.  The player colors
+// are not saved and need to be set by the caller.
+func (sg *SavedGame) GameInfo() *GameInfo {
+	gi := new(GameInfo)
+	gi.PlayerNames = sg.PlayerNames
+	gi.Computer = sg.Computer
+	gi.Species = sg.Species
+	gi.Level = sg.Level
+	gi.Rules = sg.Rules
+	gi.Seed = sg.Seed
+	gi.Map = &Map{Rows: sg.Rows, Cols: sg.Cols, StoreRow: sg.StoreRow,
+		StoreCol: sg.StoreCol, Plots: sg.Plots}
+	return gi
+}
+
+// Save writes the state of the game to a file.  It should only be
+// called between rounds.
+func (mg *MULE) Save(fname string) error {
+
+	md := mg.Model
+
+	sg := new(SavedGame)
+	sg.Version = saveVersion
+	sg.PlayerNames = mg.PlayerNames
+	for _, pc := range mg.PlotChoosers {
+		sg.Computer = append(sg.Computer, pc != nil)
+	}
+	for _, py := range md.Players {
+		sg.Species = append(sg.Species, py.species)
+	}
+	sg.Level = md.level
+	sg.Rules = md.rules
+	sg.Seed = md.seed
+	sg.Draws = md.src.n
+	sg.Round = mg.round
+	sg.Rows, sg.Cols = md.nrow, md.ncol
+	sg.StoreRow, sg.StoreCol = md.storePlot()
+	sg.Plots = md.plots
+	for _, py := range md.Players {
+		sp := savedPlayer{
+			Money:         py.money,
+			Food:          py.Food,
+			Energy:        py.Energy,
+			Smithore:      py.Smithore,
+			Crystite:      py.Crystite,
+			FoodDeficit:   py.FoodDeficit,
+			EnergyDeficit: py.EnergyDeficit,
+			Score:         py.score,
+			Rank:          py.rank,
+		}
+		sg.Players = append(sg.Players, sp)
+	}
+	sg.Store = savedStore{
+		MulePrice:     md.muleStorePrice,
+		FoodPrice:     md.foodStorePrice,
+		EnergyPrice:   md.energyStorePrice,
+		SmithorePrice: md.smithoreStorePrice,
+		CrystitePrice: md.crystiteStorePrice,
+		Food:          md.storeFood,
+		Energy:        md.storeEnergy,
+		Smithore:      md.storeSmithore,
+		Crystite:      md.storeCrystite,
+		Mules:         md.storeMules,
+	}
+	sg.PlayerEventHappened = mg.playerEventHappened
+	sg.RoundEventCounts = mg.roundEventCounts
+	sg.StarvedRounds = md.starvedRounds
+
+	b, err := json.MarshalIndent(sg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash while saving
+	// doesn't destroy the previous save
+	tmp := fname + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fname)
+}
+
+// LoadGame reads a game written by Save.
+func LoadGame(fname string) (*SavedGame, error) {
+
+	b, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	sg := new(SavedGame)
+	if err := json.Unmarshal(b, sg); err != nil {
+		return nil, err
+	}
+
+	if sg.Version != saveVersion {
+		return nil, fmt.Errorf("%s: unsupported save file version %d", fname, sg.Version)
+	}
+	// Games saved before the field size was saved have the standard
+	// field
+	if sg.Rows == 0 {
+		sg.Rows, sg.Cols = defaultRows, defaultCols
+		sg.StoreRow, sg.StoreCol = defaultRows/2, defaultCols/2
+	}
+	for _, sp := range sg.Species {
+		if sp < 0 || int(sp) >= len(speciesTraits) {
+			return nil, fmt.Errorf("%s: corrupt save file", fname)
+		}
+	}
+	if len(sg.PlayerNames) < 1 || len(sg.PlayerNames) > MaxPlayers ||
+		len(sg.Players) != len(sg.PlayerNames) || len(sg.Plots) != sg.Rows*sg.Cols {
+		return nil, fmt.Errorf("%s: corrupt save file", fname)
+	}
+
+	return sg, nil
+}
+
+// Restore puts the game back into a saved state.  The game must have
+// been set up using the saved game's GameInfo.
+func (mg *MULE) Restore(sg *SavedGame) {
+
+	md := mg.Model
+
+	md.nrow, md.ncol = sg.Rows, sg.Cols
+	md.storeRow, md.storeCol = sg.StoreRow, sg.StoreCol
+	md.plots = sg.Plots
+	for p, sp := range sg.Players {
+		py := md.Players[p]
+		py.money = sp.Money
+		py.Food = sp.Food
+		py.Energy = sp.Energy
+		py.Smithore = sp.Smithore
+		py.Crystite = sp.Crystite
+		py.FoodDeficit = sp.FoodDeficit
+		py.EnergyDeficit = sp.EnergyDeficit
+		py.score = sp.Score
+		py.rank = sp.Rank
+	}
+
+	st := sg.Store
+	md.muleStorePrice = st.MulePrice
+	md.foodStorePrice = st.FoodPrice
+	md.energyStorePrice = st.EnergyPrice
+	md.smithoreStorePrice = st.SmithorePrice
+	md.crystiteStorePrice = st.CrystitePrice
+	md.storeFood = st.Food
+	md.storeEnergy = st.Energy
+	md.storeSmithore = st.Smithore
+	md.storeCrystite = st.Crystite
+	md.storeMules = st.Mules
+	md.starvedRounds = sg.StarvedRounds
+
+	mg.round = sg.Round
+	mg.playerEventHappened = sg.PlayerEventHappened
+	if mg.playerEventHappened == nil {
+		mg.playerEventHappened = make(map[int]bool)
+	}
+	copy(mg.roundEventCounts, sg.RoundEventCounts)
+
+	// Continue the random sequence where the saved game left off
+	md.seed = sg.Seed
+	md.src = newCountingSource(sg.Seed)
+	for md.src.n < sg.Draws {
+		md.src.Int63()
+	}
+	md.rng = rand.New(md.src)
+}
@@ -0,0 +1,390 @@
+package mule
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// Messages queued for a client before it is considered too slow and
+// disconnected
+const clientQueueLen = 256
+
+// Time allowed for a new connection to say hello
+const helloTimeout = 10 * time.Second
+
+// netCell is a drawn cell sent over the network.
+type netCell struct {
+	X  int
+	Y  int
+	Ch rune
+	Fg Attribute
+	Bg Attribute
+}
+
+// serverMessage is sent from the server to its clients.  The first
+// message tells the client its seat and carries the whole screen,
+// later messages carry the cells drawn since the previous flush.
+type serverMessage struct {
+	Seat  int
+	Cells []netCell
+}
+
+// clientHello is the first message sent by a client.
+type clientHello struct {
+	Name      string
+	Spectator bool
+}
+
+// remote is a connected client.  Spectators have no seat.
+type remote struct {
+	conn   net.Conn
+	dec    *json.Decoder
+	seat   int
+	reveal bool
+	out    chan *serverMessage
+}
+
+func (rc *remote) String() string {
+	if rc.seat < 0 {
+		return fmt.Sprintf("spectator at %s", rc.conn.RemoteAddr())
+	}
+	return fmt.Sprintf("player %d", rc.seat)
+}
+
+// Server hosts a game for players on other machines.  It is the
+// Renderer of the game, sending everything drawn to its clients, and
+// the source of the game's actions, which it takes from each client
+// on behalf of that client's player only.  The game itself runs only
+// on the server.
+//
+// Spectators can connect at any time and see what the players see,
+// except for hidden information unless RevealSecrets is set.  Nothing
+// is ever read from a spectator.
+type Server struct {
+	listeners []net.Listener
+
+	mu      sync.Mutex
+	clients []*remote
+	screen  map[[2]int]netCell
+	pending []netCell
+
+	// The screen as seen by spectators that may not see secrets
+	public        map[[2]int]netCell
+	publicPending []netCell
+
+	actions chan Action
+
+	RevealSecrets bool
+	Logger        *log.Logger
+}
+
+func NewServer(ln net.Listener) *Server {
+	srv := new(Server)
+	srv.listeners = []net.Listener{ln}
+	srv.screen = make(map[[2]int]netCell)
+	srv.public = make(map[[2]int]netCell)
+	srv.actions = make(chan Action)
+	srv.Logger = log.New(ioutil.Discard, "", 0)
+	return srv
+}
+
+// Actions returns the channel of player actions, to be used as the
+// game's event queue.
+func (srv *Server) Actions() chan Action {
+	return srv.actions
+}
+
+// readHello reads the first message from a new client.  The decoder
+// is returned to read the rest of the client's messages.
+func readHello(conn net.Conn) (*json.Decoder, *clientHello, error) {
+
+	dec := json.NewDecoder(bufio.NewReader(conn))
+	hello := new(clientHello)
+	conn.SetReadDeadline(time.Now().Add(helloTimeout))
+	if err := dec.Decode(hello); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("bad hello from %s: %v", conn.RemoteAddr(), err)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	return dec, hello, nil
+}
+
+// WaitForPlayers accepts one player for each of the given seats, in
+// order, and returns the names that the players gave.  Spectators
+// that connect meanwhile are let in, and once all the players have
+// joined only spectators are accepted.
+func (srv *Server) WaitForPlayers(seats []int) ([]string, error) {
+
+	ln := srv.listeners[0]
+	var names []string
+	for len(names) < len(seats) {
+		conn, err := ln.Accept()
+		if err != nil {
+			return nil, err
+		}
+		dec, hello, err := readHello(conn)
+		if err != nil {
+			// Not a client, e.g. a port scan, keep waiting
+			srv.Logger.Print(err)
+			continue
+		}
+		if hello.Spectator {
+			srv.addClient(conn, dec, -1, srv.RevealSecrets)
+			continue
+		}
+
+		seat := seats[len(names)]
+		srv.Logger.Printf("%s joined as player %d from %s", hello.Name, seat, conn.RemoteAddr())
+		names = append(names, hello.Name)
+		srv.addClient(conn, dec, seat, true)
+	}
+
+	go srv.acceptSpectators(ln)
+
+	return names, nil
+}
+
+// Spectate accepts spectators on another listener, e.g. a local
+// socket, until the server is closed.
+func (srv *Server) Spectate(ln net.Listener) {
+	srv.mu.Lock()
+	srv.listeners = append(srv.listeners, ln)
+	srv.mu.Unlock()
+	go srv.acceptSpectators(ln)
+}
+
+func (srv *Server) acceptSpectators(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			// The server has been closed
+			return
+		}
+		dec, hello, err := readHello(conn)
+		if err != nil {
+			srv.Logger.Print(err)
+			continue
+		}
+		if !hello.Spectator {
+			srv.Logger.Printf("Turned away player %s from %s, the game is full", hello.Name, conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+		srv.addClient(conn, dec, -1, srv.RevealSecrets)
+	}
+}
+
+func (srv *Server) addClient(conn net.Conn, dec *json.Decoder, seat int, reveal bool) {
+
+	rc := &remote{conn: conn, dec: dec, seat: seat, reveal: reveal,
+		out: make(chan *serverMessage, clientQueueLen)}
+
+	srv.mu.Lock()
+	screen := srv.screen
+	if !reveal {
+		screen = srv.public
+	}
+	first := &serverMessage{Seat: seat}
+	for _, c := range screen {
+		first.Cells = append(first.Cells, c)
+	}
+	rc.out <- first
+	srv.clients = append(srv.clients, rc)
+	srv.mu.Unlock()
+
+	if seat < 0 {
+		srv.Logger.Printf("New %s", rc)
+	}
+
+	go srv.send(rc)
+	if seat >= 0 {
+		go srv.receive(rc)
+	} else {
+		go srv.ignore(rc)
+	}
+}
+
+// send writes the queued messages to a client.
+func (srv *Server) send(rc *remote) {
+	enc := json.NewEncoder(rc.conn)
+	for msg := range rc.out {
+		if err := enc.Encode(msg); err != nil {
+			srv.Logger.Printf("Lost %s: %v", rc, err)
+			srv.drop(rc)
+			return
+		}
+	}
+}
+
+// receive reads a client's actions and passes them to the game as
+// actions of the client's player.
+func (srv *Server) receive(rc *remote) {
+	for {
+		var a Action
+		if err := rc.dec.Decode(&a); err != nil {
+			srv.Logger.Printf("Lost %s: %v", rc, err)
+			srv.drop(rc)
+			return
+		}
+		a.Player = rc.seat
+		srv.actions <- a
+	}
+}
+
+// ignore reads and discards anything a spectator sends, until it
+// disconnects.
+func (srv *Server) ignore(rc *remote) {
+	io.Copy(ioutil.Discard, rc.dec.Buffered())
+	io.Copy(ioutil.Discard, rc.conn)
+	srv.Logger.Printf("Lost %s", rc)
+	srv.drop(rc)
+}
+
+// drop disconnects a client.
+func (srv *Server) drop(rc *remote) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	for k, c := range srv.clients {
+		if c == rc {
+			srv.clients = append(srv.clients[:k], srv.clients[k+1:]...)
+			close(rc.out)
+			rc.conn.Close()
+			return
+		}
+	}
+}
+
+func (srv *Server) SetCell(x, y int, c rune, fg, bg Attribute) {
+	srv.SetSecretCell(x, y, c, c, fg, bg)
+}
+
+func (srv *Server) SetSecretCell(x, y int, c, cover rune, fg, bg Attribute) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	nc := netCell{x, y, c, fg, bg}
+	srv.screen[[2]int{x, y}] = nc
+	srv.pending = append(srv.pending, nc)
+	nc.Ch = cover
+	srv.public[[2]int{x, y}] = nc
+	srv.publicPending = append(srv.publicPending, nc)
+}
+
+// Flush sends the cells drawn since the last flush to every client.
+// Clients that are too far behind are disconnected rather than
+// holding up the game.
+func (srv *Server) Flush() {
+	srv.mu.Lock()
+	if len(srv.pending) == 0 {
+		srv.mu.Unlock()
+		return
+	}
+	msg := &serverMessage{Seat: -1, Cells: srv.pending}
+	pmsg := &serverMessage{Seat: -1, Cells: srv.publicPending}
+	srv.pending = nil
+	srv.publicPending = nil
+
+	var slow []*remote
+	for _, rc := range srv.clients {
+		m := msg
+		if !rc.reveal {
+			m = pmsg
+		}
+		select {
+		case rc.out <- m:
+		default:
+			slow = append(slow, rc)
+		}
+	}
+	srv.mu.Unlock()
+
+	for _, rc := range slow {
+		srv.Logger.Printf("The %s is not keeping up", rc)
+		srv.drop(rc)
+	}
+}
+
+// Close stops accepting clients and disconnects all clients.
+func (srv *Server) Close() error {
+	srv.mu.Lock()
+	clients := append([]*remote(nil), srv.clients...)
+	listeners := srv.listeners
+	srv.mu.Unlock()
+	for _, rc := range clients {
+		srv.drop(rc)
+	}
+	var err error
+	for _, ln := range listeners {
+		if e := ln.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// drawRemote reads the server's messages and draws them with r until the
+// connection is closed.
+func drawRemote(conn net.Conn, r Renderer) {
+	dec := json.NewDecoder(bufio.NewReader(conn))
+	for {
+		var msg serverMessage
+		if err := dec.Decode(&msg); err != nil {
+			return
+		}
+		for _, c := range msg.Cells {
+			r.SetCell(c.X, c.Y, c.Ch, c.Fg, c.Bg)
+		}
+		r.Flush()
+	}
+}
+
+// Join connects to a server as a player called name, draws the game
+// with r, and sends the actions read from acts, until the connection
+// is closed.  The returned error is nil if the server ended the game.
+func Join(conn net.Conn, name string, r Renderer, acts <-chan Action) error {
+
+	if err := json.NewEncoder(conn).Encode(&clientHello{Name: name}); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		drawRemote(conn, r)
+	}()
+
+	enc := json.NewEncoder(conn)
+	for {
+		select {
+		case <-done:
+			return nil
+		case a, ok := <-acts:
+			if !ok {
+				conn.Close()
+				return nil
+			}
+			if err := enc.Encode(&a); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Watch connects to a server as a spectator and draws the game with r
+// until the connection is closed.
+func Watch(conn net.Conn, r Renderer) error {
+
+	if err := json.NewEncoder(conn).Encode(&clientHello{Spectator: true}); err != nil {
+		return err
+	}
+	drawRemote(conn, r)
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package mule
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// waitFor polls f until it returns true or a second has passed.
+func waitFor(t *testing.T, what string, f func() bool) {
+	t.Helper()
+	for k := 0; k < 1000; k++ {
+		if f() {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestServerLoopback(t *testing.T) {
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(ln)
+	defer srv.Close()
+
+	type joined struct {
+		names []string
+		err   error
+	}
+	jc := make(chan joined)
+	go func() {
+		names, err := srv.WaitForPlayers([]int{1})
+		jc <- joined{names, err}
+	}()
+
+	// Something that isn't a client mustn't stop the server
+	junk, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	junk.Write([]byte("GET / HTTP/1.0\r\n\r\n"))
+	junk.Close()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := NewRecordingRenderer()
+	acts := make(chan Action)
+	done := make(chan error)
+	go func() { done <- Join(conn, "ann", rr, acts) }()
+
+	j := <-jc
+	if j.err != nil {
+		t.Fatal(j.err)
+	}
+	if len(j.names) != 1 || j.names[0] != "ann" {
+		t.Fatalf("got players %v", j.names)
+	}
+
+	// What the game draws reaches the client
+	srv.SetCell(3, 4, 'X', ColorRed, ColorBlack)
+	srv.Flush()
+	waitFor(t, "the cell to be drawn", func() bool { return rr.Cell(3, 4).Ch == 'X' })
+
+	// The client can only act for its own seat
+	acts <- Action{Player: 0, Type: ActionConfirm}
+	select {
+	case a := <-srv.Actions():
+		if a.Player != 1 || a.Type != ActionConfirm {
+			t.Fatalf("got action %+v", a)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no action from the client")
+	}
+
+	srv.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("client didn't see the server close")
+	}
+}
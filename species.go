@@ -0,0 +1,74 @@
+package mule
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Species is the kind of creature a player is.  The zero value is the
+// Mechtron, which has no advantages or handicaps.
+type Species int
+
+const (
+	SpeciesMechtron Species = iota
+	SpeciesGollumer
+	SpeciesPacker
+	SpeciesBonzoid
+	SpeciesSpheroid
+	SpeciesLeggite
+	SpeciesFlapper
+	SpeciesHumanoid
+)
+
+// speciesTraits are the starting money, relative to the rules, and the
+// percentage of moves lost to slowness, on top of what the terrain
+// costs.  Fast species have a negative slowness, which is taken off
+// the moves that everyone loses, so they gain on any terrain.
+var speciesTraits = []struct {
+	name     string
+	money    int
+	slowness int
+}{
+	SpeciesMechtron: {"Mechtron", 0, 0},
+	SpeciesGollumer: {"Gollumer", 100, 10},
+	SpeciesPacker:   {"Packer", 200, 15},
+	SpeciesBonzoid:  {"Bonzoid", 0, -5},
+	SpeciesSpheroid: {"Spheroid", -100, -10},
+	SpeciesLeggite:  {"Leggite", -200, -15},
+	SpeciesFlapper:  {"Flapper", 600, 0},
+	SpeciesHumanoid: {"Humanoid", -400, -10},
+}
+
+func (s Species) String() string {
+	return speciesTraits[s].name
+}
+
+// ParseSpecies returns the species with the given name.
+func ParseSpecies(s string) (Species, error) {
+	for sp, tr := range speciesTraits {
+		if strings.EqualFold(s, tr.name) {
+			return Species(sp), nil
+		}
+	}
+	return SpeciesMechtron, fmt.Errorf("unknown species %q", s)
+}
+
+// speciesNames returns the names of all the species, for prompts.
+func speciesNames() []string {
+	var na []string
+	for _, tr := range speciesTraits {
+		na = append(na, strings.ToLower(tr.name))
+	}
+	return na
+}
+
+// startMoney returns the money a player of this species starts with.
+func (s Species) startMoney(rl *Rules) int {
+	return rl.StartMoney + speciesTraits[s].money
+}
+
+// slowness returns the extra percentage of moves that a player of
+// this species loses.
+func (s Species) slowness() int {
+	return speciesTraits[s].slowness
+}
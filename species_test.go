@@ -0,0 +1,29 @@
+package mule
+
+import "testing"
+
+func TestSlowness(t *testing.T) {
+
+	mg, _, _, _ := newTestGame()
+	v := &mg.Fieldview.view
+	py := mg.Model.Players[0]
+
+	// Plain land, mountains and the river
+	for _, delay := range []int{0, 1, 2, 3} {
+		v.delay = delay
+		py.species = SpeciesMechtron
+		base := v.slowness(0)
+		for sp, tr := range speciesTraits {
+			py.species = Species(sp)
+			s := v.slowness(0)
+			switch {
+			case s < 0 || s > 100:
+				t.Errorf("%s loses %d%% of moves at delay %d", tr.name, s, delay)
+			case tr.slowness < 0 && s >= base:
+				t.Errorf("%s is no faster than a Mechtron at delay %d", tr.name, delay)
+			case tr.slowness > 0 && s <= base:
+				t.Errorf("%s is no slower than a Mechtron at delay %d", tr.name, delay)
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package mule
+
+import "github.com/nsf/termbox-go"
+
+// TermboxRenderer draws the game on the terminal.  termbox must be
+// initialized before any drawing is done.
+type TermboxRenderer struct{}
+
+func (TermboxRenderer) SetCell(x, y int, c rune, fg, bg Attribute) {
+	termbox.SetCell(x, y, c, termboxAttribute(fg), termboxAttribute(bg))
+}
+
+func (TermboxRenderer) Flush() {
+	termbox.Flush()
+}
+
+func termboxAttribute(a Attribute) termbox.Attribute {
+	t := termbox.Attribute(a & 0xff)
+	if a&AttrBold != 0 {
+		t |= termbox.AttrBold
+	}
+	if a&AttrUnderline != 0 {
+		t |= termbox.AttrUnderline
+	}
+	if a&AttrReverse != 0 {
+		t |= termbox.AttrReverse
+	}
+	return t
+}
+
+// TermboxActions translates a termbox event from the shared keyboard
+// into game actions.  Some keys mean different things in different
+// phases (e.g. 'a' is both an assay and player 1's plot key), so more
+// than one action may be returned; the phases ignore actions that do
+// not apply to them.
+func TermboxActions(ev termbox.Event) []Action {
+
+	if ev.Type != termbox.EventKey {
+		return nil
+	}
+
+	var acts []Action
+	switch ev.Key {
+	case termbox.KeyArrowUp:
+		acts = append(acts, Action{Player: AnyPlayer, Type: ActionMove, Dir: DirUp})
+	case termbox.KeyArrowDown:
+		acts = append(acts, Action{Player: AnyPlayer, Type: ActionMove, Dir: DirDown})
+	case termbox.KeyArrowLeft:
+		acts = append(acts, Action{Player: AnyPlayer, Type: ActionMove, Dir: DirLeft})
+	case termbox.KeyArrowRight:
+		acts = append(acts, Action{Player: AnyPlayer, Type: ActionMove, Dir: DirRight})
+	case termbox.KeySpace:
+		acts = append(acts, Action{Player: AnyPlayer, Type: ActionConfirm})
+	case termbox.KeyBackspace2:
+		acts = append(acts, Action{Player: AnyPlayer, Type: ActionEndPhase})
+	case termbox.KeyEsc:
+		acts = append(acts, Action{Player: AnyPlayer, Type: ActionPause})
+	}
+
+	if ev.Ch == 'a' {
+		acts = append(acts, Action{Player: AnyPlayer, Type: ActionAssay})
+	}
+	if ev.Ch == 's' {
+		acts = append(acts, Action{Player: AnyPlayer, Type: ActionSellPlot})
+	}
+	for p, c := range selectKeys {
+		if ev.Ch == c {
+			acts = append(acts, Action{Player: p, Type: ActionClaimPlot},
+				Action{Player: p, Type: ActionCollude})
+		}
+	}
+	for k, c := range pkeys {
+		if ev.Ch == c {
+			if k%2 == 0 {
+				acts = append(acts, Action{Player: k / 2, Type: ActionAuctionUp})
+			} else {
+				acts = append(acts, Action{Player: k / 2, Type: ActionAuctionDown})
+			}
+		}
+	}
+
+	return acts
+}
+
+// RemoteActions translates a termbox event from a player's own
+// keyboard into game actions.  The server treats every action from a
+// remote player as belonging to that player, so any of the player
+// keys will do, and the up and down arrows also work in auctions.
+func RemoteActions(ev termbox.Event) []Action {
+
+	acts := TermboxActions(ev)
+	if ev.Type == termbox.EventKey {
+		switch ev.Key {
+		case termbox.KeyArrowUp:
+			acts = append(acts, Action{Player: AnyPlayer, Type: ActionAuctionUp})
+		case termbox.KeyArrowDown:
+			acts = append(acts, Action{Player: AnyPlayer, Type: ActionAuctionDown})
+		}
+	}
+	return acts
+}
@@ -1,6 +1,6 @@
 package mule
 
-import "github.com/nsf/termbox-go"
+import "fmt"
 
 type view struct {
 	mule *MULE
@@ -18,8 +18,8 @@ type view struct {
 	delay int
 
 	backing_rune []rune
-	backing_fg   []termbox.Attribute
-	backing_bg   []termbox.Attribute
+	backing_fg   []Attribute
+	backing_bg   []Attribute
 	bounds       []bool
 }
 
@@ -34,7 +34,7 @@ const (
 	timeX0 int = 65
 )
 
-func (v *view) Print(x, y int, msg string, fg, bg termbox.Attribute, add bool, addb bool) {
+func (v *view) Print(x, y int, msg string, fg, bg Attribute, add bool, addb bool) {
 	mg := v.mule
 	for _, c := range msg {
 		if add {
@@ -46,24 +46,24 @@ func (v *view) Print(x, y int, msg string, fg, bg termbox.Attribute, add bool, a
 				v.bounds[i] = true
 			}
 		}
-		termbox.SetCell(mg.x0+x, mg.y0+y, c, fg, bg)
+		mg.Renderer.SetCell(mg.x0+x, mg.y0+y, c, fg, bg)
 		x++
 	}
 }
 
-func (v *view) Banner(msg []string, fg, bg termbox.Attribute) {
+func (v *view) Banner(msg []string, fg, bg Attribute) {
 
 	mg := v.mule
 	y := 0
 	for _, mv := range msg {
 
 		for j := 0; j < 2*mg.w; j++ {
-			termbox.SetCell(j, y, ' ', fg, bg)
+			mg.Renderer.SetCell(j, y, ' ', fg, bg)
 		}
 
 		x := 2
 		for _, c := range mv {
-			termbox.SetCell(x, y, c, fg, bg)
+			mg.Renderer.SetCell(x, y, c, fg, bg)
 			x++
 		}
 		y++
@@ -71,16 +71,17 @@ func (v *view) Banner(msg []string, fg, bg termbox.Attribute) {
 }
 
 func (v *view) PrintTime(msg string) {
+	v.mule.timeMsg = msg
 	for k, c := range msg {
-		termbox.SetCell(timeX0+k, 2, c, termbox.ColorWhite, termbox.ColorBlack)
+		v.mule.Renderer.SetCell(timeX0+k, 2, c, ColorWhite, ColorBlack)
 	}
-	termbox.Flush()
+	v.mule.Renderer.Flush()
 }
 
-func (v *view) DrawHline(x1, x2, y int, c rune, fg, bg termbox.Attribute) {
+func (v *view) DrawHline(x1, x2, y int, c rune, fg, bg Attribute) {
 	mg := v.mule
 	for x := x1; x <= x2; x++ {
-		termbox.SetCell(mg.x0+x, mg.y0+y, c, fg, bg)
+		mg.Renderer.SetCell(mg.x0+x, mg.y0+y, c, fg, bg)
 		i := y*mg.w + x
 		v.bounds[i] = true
 		v.backing_rune[i] = c
@@ -89,10 +90,10 @@ func (v *view) DrawHline(x1, x2, y int, c rune, fg, bg termbox.Attribute) {
 	}
 }
 
-func (v *view) DrawVline(x, y1, y2 int, c rune, fg, bg termbox.Attribute) {
+func (v *view) DrawVline(x, y1, y2 int, c rune, fg, bg Attribute) {
 	mg := v.mule
 	for y := y1; y <= y2; y++ {
-		termbox.SetCell(mg.x0+x, mg.y0+y, c, fg, bg)
+		mg.Renderer.SetCell(mg.x0+x, mg.y0+y, c, fg, bg)
 		i := y*mg.w + x
 		v.bounds[i] = true
 		v.backing_rune[i] = c
@@ -101,64 +102,88 @@ func (v *view) DrawVline(x, y1, y2 int, c rune, fg, bg termbox.Attribute) {
 	}
 }
 
-func (v *view) turn(p int, pr rune, prc termbox.Attribute, tf func(v *view, x, y int) location,
-	kh func(*view, termbox.Event) continueType) location {
+// Percentage of moves that everyone loses, enough that the fastest
+// species loses none on plain land, and that lost to each level of
+// the terrain's delay
+const (
+	baseSlowness    = 15
+	terrainSlowness = 20
+)
+
+// slowness returns the percentage of player p's moves that are lost
+// to the terrain and to the player's species.
+func (v *view) slowness(p int) int {
+	return baseSlowness + terrainSlowness*v.delay + v.mule.Model.Players[p].species.slowness()
+}
+
+// slow returns true if player p's move is lost.  The game's random
+// number generator decides, so that a replay loses the same moves.
+func (v *view) slow(p int) bool {
+	return v.mule.Model.rng.Intn(100) < v.slowness(p)
+}
+
+// turn runs the event loop of a player's turn in a view.  tf is
+// called with each new position of the player and kh with the other
+// actions.  wh is called when the wumpus of the hunt wumpus moves,
+// which is nil where there is no wumpus.
+func (v *view) turn(p int, pr rune, prc Attribute, tf func(v *view, x, y int) location,
+	kh func(*view, Action) continueType, wumpus *wumpusHunt, wh func(*view)) location {
 
 	mg := v.mule
+	mg.startPhase()
 
 	// Main event loop
-	for cnt := 0; ; cnt++ {
+	for {
+		tick, wc := mg.turnTick(), wumpus.C()
 	ax:
 		select {
-		case <-v.mule.mainTimer.C:
-			return locTimeout
-
-		case msg := <-v.mule.timerinfo:
-			v.PrintTime(msg)
-
-		case stat := <-v.mule.wumpusStatus:
-			if v.mule.currentStage == stageLiveField {
-				if stat.active {
-					v.Print(stat.x, stat.y, "W", termbox.ColorWhite, termbox.ColorBlack, false, false)
-				} else {
-					v.RestorePoint(stat.x, stat.y)
-				}
-				termbox.Flush()
+		case <-tick:
+			mg.woke(tick)
+			left := mg.turnLeft()
+			if left <= 0 {
+				return locTimeout
 			}
+			mg.timeRemaining = left
+			v.PrintTime(fmt.Sprintf("Time: %2ds", left))
+
+		case <-wc:
+			mg.woke(wc)
+			wh(v)
 
-		case ev := <-v.mule.eventQueue:
-			if ev.Type == termbox.EventKey {
+		case a := <-mg.input(tick, wc):
+			mg.took(a)
+			if a.from(p) {
 				newX := v.xpos
 				newY := v.ypos
 				switch {
-				case ev.Ch == 'a':
+				case a.Type == ActionAssay:
 					if mg.currentStage == stageLiveField {
 						mg.assay_x = v.xpos
 						mg.assay_y = v.ypos
 						mg.Banner("Soil sample obtained, return to assay office for processing", 0)
 					}
-				case ev.Key == termbox.KeyArrowUp:
-					if cnt%100 < 20*v.delay {
+				case a.Type == ActionMove && a.Dir == DirUp:
+					if v.slow(p) {
 						continue
 					}
 					newY--
-				case ev.Key == termbox.KeyArrowLeft:
-					if cnt%100 < 20*v.delay {
+				case a.Type == ActionMove && a.Dir == DirLeft:
+					if v.slow(p) {
 						continue
 					}
 					newX--
-				case ev.Key == termbox.KeyArrowRight:
-					if cnt%100 < 20*v.delay {
+				case a.Type == ActionMove && a.Dir == DirRight:
+					if v.slow(p) {
 						continue
 					}
 					newX++
-				case ev.Key == termbox.KeyArrowDown:
-					if cnt%100 < 20*v.delay {
+				case a.Type == ActionMove && a.Dir == DirDown:
+					if v.slow(p) {
 						continue
 					}
 					newY++
 				default:
-					cont := kh(v, ev)
+					cont := kh(v, a)
 					if cont == continueTypeSwitch {
 						return locStoreNone
 					}
@@ -191,7 +216,7 @@ func (v *view) turn(p int, pr rune, prc termbox.Attribute, tf func(v *view, x, y
 				for k := 0; k < v.iql; k++ {
 					if v.xposq[k] != -1 && v.yposq[k] != -1 {
 						i := v.yposq[k]*mg.w + v.xposq[k]
-						termbox.SetCell(mg.x0+v.xposq[k], mg.y0+v.yposq[k], v.backing_rune[i],
+						mg.Renderer.SetCell(mg.x0+v.xposq[k], mg.y0+v.yposq[k], v.backing_rune[i],
 							v.backing_fg[i], v.backing_bg[i])
 					}
 				}
@@ -205,17 +230,15 @@ func (v *view) turn(p int, pr rune, prc termbox.Attribute, tf func(v *view, x, y
 
 				// Draw player piece at new position
 				i := newY*mg.w + newX
-				termbox.SetCell(mg.x0+newX, mg.y0+newY, pr, prc, v.backing_bg[i])
+				mg.Renderer.SetCell(mg.x0+newX, mg.y0+newY, pr, prc, v.backing_bg[i])
 
 				// Draw the mule
 				if v.mule.Model.Players[p].hasMule {
 					v.DrawMule(p)
 				}
-				termbox.Flush()
+				mg.Renderer.Flush()
 
 			}
-		default:
-			// nothing
 		}
 	}
 
@@ -238,7 +261,7 @@ func (v *view) DrawPlayer(p, x, y int) {
 	v.Print(x, y, "Y", v.mule.PlayerColors[p], v.backing_bg[i], false, false)
 }
 
-func (v *view) RemoveMule(col termbox.Attribute) {
+func (v *view) RemoveMule(col Attribute) {
 	mg := v.mule
 	for k := 0; k < v.iql; k++ {
 		if v.xposq[k] != -1 {
@@ -280,11 +303,11 @@ func (v *view) DrawMule(p int) {
 
 	if x1 != -1 {
 		i := y1*mg.w + x1
-		termbox.SetCell(mg.x0+x1, mg.y0+y1, c, prc, v.backing_bg[i])
+		mg.Renderer.SetCell(mg.x0+x1, mg.y0+y1, c, prc, v.backing_bg[i])
 	}
 	if x2 != -1 {
 		i := y2*mg.w + x2
-		termbox.SetCell(mg.x0+x2, mg.y0+y2, pl.muleSymbol, prc, v.backing_bg[i])
+		mg.Renderer.SetCell(mg.x0+x2, mg.y0+y2, pl.muleSymbol, prc, v.backing_bg[i])
 	}
 }
 
@@ -294,8 +317,8 @@ func (v *view) view_init() {
 	m := mg.w * mg.h
 	v.bounds = make([]bool, m)
 	v.backing_rune = make([]rune, m)
-	v.backing_fg = make([]termbox.Attribute, m)
-	v.backing_bg = make([]termbox.Attribute, m)
+	v.backing_fg = make([]Attribute, m)
+	v.backing_bg = make([]Attribute, m)
 	v.xposq = make([]int, v.iql)
 	v.yposq = make([]int, v.iql)
 }
@@ -303,11 +326,11 @@ func (v *view) view_init() {
 func (v *view) Clear() {
 
 	mg := v.mule
-	bg := termbox.ColorBlack
+	bg := ColorBlack
 
 	for i := 0; i < mg.h; i++ {
 		for j := 0; j < mg.w; j++ {
-			termbox.SetCell(mg.x0+j, mg.y0+i, ' ', bg, bg)
+			mg.Renderer.SetCell(mg.x0+j, mg.y0+i, ' ', bg, bg)
 			k := mg.w*i + j
 			v.backing_rune[k] = ' '
 			v.backing_fg[k] = bg
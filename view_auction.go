@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"strings"
 	"time"
-
-	"github.com/nsf/termbox-go"
 )
 
 type AuctionView struct {
@@ -27,11 +25,18 @@ type AuctionView struct {
 	newpos       []int
 	pastCritical []bool
 	canSell      []bool
+	input        []bidInput
+	colluding    []bool
+
+	// The plot being sold in a land auction, and its seller (-1 for
+	// the colony)
+	lot       *Plot
+	lotSeller int
 }
 
 var (
 	rtnames = map[resourceType]string{food: "Food", energy: "Energy",
-		smithore: "Smithore", crystite: "Crystite"}
+		smithore: "Smithore", crystite: "Crystite", land: "Land"}
 )
 
 type resourceType int
@@ -41,6 +46,7 @@ const (
 	energy
 	smithore
 	crystite
+	land
 )
 
 type typeBuySell int
@@ -61,20 +67,30 @@ const (
 	ay0 int = -8 // negative because we count from the bottom
 )
 
+// Up/down key pairs for each player, the first four are spread out
+// over the keyboard
 var (
-	pkeys = []rune{'1', 'q', 'f', 'v', '8', 'i', ';', '/'}
+	pkeys = []rune{'1', 'q', 'f', 'v', '8', 'i', ';', '/',
+		'3', 'e', 'h', 'n', '5', 't', '0', 'p'}
 )
 
 func NewAuctionView() *AuctionView {
 	av := new(AuctionView)
 	av.barw = 60
-	av.colw = 12
 	return av
 }
 
 func (av *AuctionView) Init(atp resourceType, r int) {
+	av.initState(atp)
+	av.minPrice, av.maxprice = av.mule.Model.getStoreSellPrice(atp, r)
+}
+
+// initState sets up the players for an auction of atp, without
+// touching the store's prices.
+func (av *AuctionView) initState(atp resourceType) {
 	mg := av.mule
 	av.aucType = atp
+	av.colw = av.barw / (mg.nplayers + 1)
 	av.buySell = make([]typeBuySell, mg.nplayers)
 	av.canSell = make([]bool, mg.nplayers)
 	av.pastCritical = make([]bool, mg.nplayers)
@@ -83,20 +99,16 @@ func (av *AuctionView) Init(atp resourceType, r int) {
 		av.pos[k] = barmin
 	}
 	av.newpos = make([]int, mg.nplayers)
+	av.input = make([]bidInput, mg.nplayers)
+	av.colluding = make([]bool, mg.nplayers)
 	av.barposu = barmax
 	av.barposl = barmin
-
-	av.minPrice, av.maxprice = av.mule.Model.getStoreSellPrice(atp, r)
-}
-
-func (av *AuctionView) PrintTime(msg string) {
-
 }
 
 func (av *AuctionView) printPlayerAmounts() {
 	mg := av.mule
 
-	bg := termbox.ColorBlack
+	bg := ColorBlack
 	for p := 0; p < av.mule.nplayers; p++ {
 		py := av.mule.Model.Players[p]
 		var amt, rqamt int
@@ -113,6 +125,13 @@ func (av *AuctionView) printPlayerAmounts() {
 		case crystite:
 			amt = py.Crystite
 			rqamt = 0
+		case land:
+			for _, plt := range av.mule.Model.plots {
+				if plt.Owned && plt.Owner == p {
+					amt++
+				}
+			}
+			rqamt = 0
 		}
 
 		// Goods
@@ -134,16 +153,16 @@ func (av *AuctionView) printLimitPrices() {
 	mg := av.mule
 
 	s := fmt.Sprintf("%5d", av.minPrice)
-	av.Print(av.barw+3, mg.h-barmin, s, termbox.ColorWhite, termbox.ColorBlack)
+	av.Print(av.barw+3, mg.h-barmin, s, ColorWhite, ColorBlack)
 	s = fmt.Sprintf("%5d", av.maxprice)
-	av.Print(av.barw+3, mg.h-barmax, s, termbox.ColorWhite, termbox.ColorBlack)
+	av.Print(av.barw+3, mg.h-barmax, s, ColorWhite, ColorBlack)
 }
 
 func (av *AuctionView) Render() {
 	av.printLimitPrices()
 	av.printStoreAmount()
 	av.printPlayerAmounts()
-	termbox.Flush()
+	av.mule.Renderer.Flush()
 }
 
 // draw the upper/lower limits as --- or ====
@@ -158,25 +177,41 @@ func (av *AuctionView) drawLimitsSelect() {
 		}
 	}
 	s := strings.Repeat("-", av.barw)
-	av.Print(0, mg.h-barmax, s, termbox.ColorWhite, termbox.ColorBlack)
-	av.Print(0, mg.h-barmin, s, termbox.ColorWhite, termbox.ColorBlack)
+	av.Print(0, mg.h-barmax, s, ColorWhite, ColorBlack)
+	av.Print(0, mg.h-barmin, s, ColorWhite, ColorBlack)
 }
 
 func (av *AuctionView) drawLimitsAuction() {
 	mg := av.mule
 	s := strings.Repeat("-", av.barw)
-	av.Print(0, mg.h-barmax, s, termbox.ColorWhite, termbox.ColorBlack)
-	av.Print(0, mg.h-barmin, s, termbox.ColorWhite, termbox.ColorBlack)
+	av.Print(0, mg.h-barmax, s, ColorWhite, ColorBlack)
+	av.Print(0, mg.h-barmin, s, ColorWhite, ColorBlack)
 }
 
 func (av *AuctionView) keyMsg() string {
-	msg := fmt.Sprintf("Player keys: %s (%s/%s)", av.mule.PlayerNames[0],
-		string(pkeys[0]), string(pkeys[1]))
-	for j := 1; j < av.mule.nplayers; j++ {
-		msg += fmt.Sprintf("  %s (%s/%s)", av.mule.PlayerNames[j],
-			string(pkeys[2*j]), string(pkeys[2*j+1]))
+	var w []string
+	for j := 0; j < av.mule.nplayers; j++ {
+		if av.mule.Bidders[j] != nil {
+			w = append(w, fmt.Sprintf("%s (computer)", av.mule.PlayerNames[j]))
+		} else if av.aucType != land && av.mule.Model.level.collusion() {
+			w = append(w, fmt.Sprintf("%s (%s/%s, %s)", av.mule.PlayerNames[j],
+				string(pkeys[2*j]), string(pkeys[2*j+1]), string(selectKeys[j])))
+		} else {
+			w = append(w, fmt.Sprintf("%s (%s/%s)", av.mule.PlayerNames[j],
+				string(pkeys[2*j]), string(pkeys[2*j+1])))
+		}
 	}
-	return msg
+	return "Player keys: " + strings.Join(w, "  ")
+}
+
+// humanPlayer returns the player that an action belongs to, or -1 if
+// it does not belong to a human player of this game.
+func (av *AuctionView) humanPlayer(a Action) int {
+	p := a.player(av.mule.nplayers)
+	if p >= 0 && av.mule.Bidders[p] != nil {
+		return -1
+	}
+	return p
 }
 
 func (av *AuctionView) skipAuction() bool {
@@ -267,11 +302,18 @@ func (av *AuctionView) DoDeclaration(r int) bool {
 	mg := av.mule
 	av.SetInitialDeclarationStatus()
 
+	// Computer players declare straight away
+	for p, b := range mg.Bidders {
+		if b != nil {
+			av.buySell[p] = b.Declare(av, p)
+		}
+	}
+
 	av.Clear()
 	av.drawLimitsSelect()
 	av.drawLabels()
 	av.Render()
-	bg := termbox.ColorBlack
+	bg := ColorBlack
 
 	for p := 0; p < mg.nplayers; p++ {
 		col := mg.PlayerColors[p]
@@ -289,69 +331,35 @@ func (av *AuctionView) DoDeclaration(r int) bool {
 	mg.Banner(msg, 0)
 	msg = av.keyMsg()
 	mg.Banner(msg, 1)
-	mg.WaitForSpace()
+	mg.WaitForSpace(AnyPlayer)
 	msg = fmt.Sprintf("Declaring buy/sell in the %s auction... (press backspace to end)", rtnames[av.aucType])
 	mg.Banner(msg, 0)
 
-	timer := time.NewTimer(time.Duration(5) * time.Second)
+	mg.startPhase()
+	timer := mg.Clock.NewTimer(time.Duration(5) * time.Second)
+	defer timer.Stop()
+	end := mg.newVotes()
 
 	for {
 		select {
-		case <-timer.C:
+		case <-timer.C():
+			mg.woke(timer.C())
 			return true
 
-		case msg := <-mg.timerinfo:
-			av.PrintTime(msg)
-
-		case ev := <-mg.eventQueue:
-			if ev.Type != termbox.EventKey {
-				break
-			}
+		case a := <-mg.input(timer.C()):
+			mg.took(a)
+			p := av.humanPlayer(a)
 			switch {
-			case ev.Ch == pkeys[0]:
-				// Player 1 up
-				if mg.nplayers >= 1 && av.canSell[0] {
-					av.buySell[0] = seller
-				}
-			case ev.Ch == pkeys[1]:
-				// Player 1 down
-				if mg.nplayers >= 1 {
-					av.buySell[0] = buyer
-				}
-			case ev.Ch == pkeys[2]:
-				// Player 2 up
-				if mg.nplayers >= 2 && av.canSell[1] {
-					av.buySell[1] = seller
-				}
-			case ev.Ch == pkeys[3]:
-				// Player 2 down
-				if mg.nplayers >= 2 {
-					av.buySell[1] = buyer
-				}
-			case ev.Ch == pkeys[4]:
-				// Player 3 up
-				if mg.nplayers >= 3 && av.canSell[2] {
-					av.buySell[2] = seller
-				}
-			case ev.Ch == pkeys[5]:
-				// Player 3 down
-				if mg.nplayers >= 3 {
-					av.buySell[2] = buyer
-				}
-			case ev.Ch == pkeys[6]:
-				// Player 4 up
-				if mg.nplayers >= 4 && av.canSell[3] {
-					av.buySell[3] = seller
-				}
-			case ev.Ch == pkeys[7]:
-				// Player 4 down
-				if mg.nplayers >= 4 {
-					av.buySell[3] = buyer
+			case a.Type == ActionAuctionUp && p >= 0:
+				if av.canSell[p] {
+					av.buySell[p] = seller
 				}
-			case ev.Key == termbox.KeyBackspace2:
+			case a.Type == ActionAuctionDown && p >= 0:
+				av.buySell[p] = buyer
+			case a.Type == ActionEndPhase && end.add(a):
 				mg.Banner("Declaring ended early!", 0)
-				termbox.Flush()
-				time.Sleep(1 * time.Second)
+				mg.Renderer.Flush()
+				mg.sleep(1 * time.Second)
 				return true
 			}
 		}
@@ -379,7 +387,7 @@ func (av *AuctionView) DoDeclaration(r int) bool {
 			av.Print((p+1)*av.colw-1, mg.h-y3, txt, col, bg)
 			av.Print((p+1)*av.colw-1, mg.h-y4, "    ", col, bg)
 		}
-		termbox.Flush()
+		mg.Renderer.Flush()
 	}
 
 	return true
@@ -387,7 +395,7 @@ func (av *AuctionView) DoDeclaration(r int) bool {
 
 func (av *AuctionView) drawPlayers() {
 	mg := av.mule
-	bg := termbox.ColorBlack
+	bg := ColorBlack
 	for p := 0; p < mg.nplayers; p++ {
 		col := mg.PlayerColors[p]
 		av.Print((p+1)*av.colw, mg.h-av.pos[p], "Y", col, bg)
@@ -396,7 +404,7 @@ func (av *AuctionView) drawPlayers() {
 
 func (av *AuctionView) removePlayers() {
 	mg := av.mule
-	bg := termbox.ColorBlack
+	bg := ColorBlack
 	for p := 0; p < mg.nplayers; p++ {
 		av.Print((p+1)*av.colw, mg.h-av.pos[p], " ", bg, bg)
 	}
@@ -404,8 +412,8 @@ func (av *AuctionView) removePlayers() {
 
 func (av *AuctionView) drawLabels() {
 	mg := av.mule
-	fg := termbox.ColorWhite
-	bg := termbox.ColorBlack
+	fg := ColorWhite
+	bg := ColorBlack
 	av.Print(av.barw+2, mg.h-barmin+3, rtnames[av.aucType]+"     ", fg, bg)
 	av.Print(av.barw+2, mg.h-barmin+4, "Required     ", fg, bg)
 	av.Print(av.barw+2, mg.h-barmin+5, "Money     ", fg, bg)
@@ -438,7 +446,7 @@ func (av *AuctionView) checkSellers() bool {
 	}
 
 	mg.Banner("No sellers, no auction.", 0)
-	time.Sleep(2 * time.Second)
+	mg.sleep(2 * time.Second)
 
 	return false
 }
@@ -473,9 +481,13 @@ func (av *AuctionView) DoAuction(r int) {
 	av.drawLimitsAuction()
 	av.drawPlayers()
 	av.drawLabels()
-	termbox.Flush()
-	mg.WaitForSpace()
-	mg.Banner(fmt.Sprintf("%s auction... (press backspace to end)", rtnames[av.aucType]), 0)
+	mg.Renderer.Flush()
+	mg.WaitForSpace(AnyPlayer)
+	msg = fmt.Sprintf("%s auction... (press backspace to end)", rtnames[av.aucType])
+	if mg.Model.level.collusion() {
+		msg = fmt.Sprintf("%s auction... (press your plot key to collude, backspace to end)", rtnames[av.aucType])
+	}
+	mg.Banner(msg, 0)
 
 	av.RunAuction()
 }
@@ -536,18 +548,18 @@ func (av *AuctionView) clipPos(pos []int) {
 
 func (av *AuctionView) Clear() {
 	mg := av.mule
-	bg := termbox.ColorBlack
+	bg := ColorBlack
 	for i := 0; i < 40; i++ {
 		for j := 0; j < 80; j++ {
-			termbox.SetCell(j, mg.y0+i, ' ', bg, bg)
+			mg.Renderer.SetCell(j, mg.y0+i, ' ', bg, bg)
 		}
 	}
 }
 
 func (av *AuctionView) removeBars() {
 	mg := av.mule
-	fg := termbox.ColorWhite
-	bg := termbox.ColorBlack
+	fg := ColorWhite
+	bg := ColorBlack
 	m := av.barw
 	if av.barposu != barmax && av.barposu != barmin {
 		m += 10
@@ -565,8 +577,8 @@ func (av *AuctionView) removeBars() {
 
 func (av *AuctionView) redrawBars(barposl, barposu int) {
 	mg := av.mule
-	fg := termbox.ColorWhite
-	bg := termbox.ColorBlack
+	fg := ColorWhite
+	bg := ColorBlack
 
 	av.removePlayers()
 	av.removeBars()
@@ -618,7 +630,7 @@ func (av *AuctionView) printStoreAmount() {
 	}
 
 	s := fmt.Sprintf("[%d]  ", x)
-	av.Print(av.barw+10, mg.h-barmax, s, termbox.ColorWhite, termbox.ColorBlack)
+	av.Print(av.barw+10, mg.h-barmax, s, ColorWhite, ColorBlack)
 }
 
 func (av *AuctionView) AnySellers() bool {
@@ -637,7 +649,10 @@ func (av *AuctionView) RunAuction() {
 	mg := av.mule
 	md := mg.Model
 
-	timer := time.NewTimer(time.Duration(30) * time.Second)
+	mg.startPhase()
+	timer := mg.Clock.NewTimer(time.Duration(30) * time.Second)
+	defer timer.Stop()
+	end := mg.newVotes()
 	copy(av.newpos, av.pos)
 	haveSellers := av.AnySellers()
 
@@ -661,78 +676,67 @@ func (av *AuctionView) RunAuction() {
 	sellToStoren := 0
 	sellToPlayern := 0
 	buyFromStoren := 0
+	colluden := 0
+
+	// The auction moves on a steady tick, every player at most one
+	// step a tick, with every move resolved at the time of the tick,
+	// so that it doesn't matter whose key events arrive first
+	tick := mg.Clock.NewTicker(eventDelay)
+	defer tick.Stop()
 
 	// Main event loop
-	for cnt := 0; ; cnt++ {
+	var now time.Time
+	for {
 
 		select {
-		case <-timer.C:
+		case now = <-tick.C():
+			mg.woke(tick.C())
+
+		case <-timer.C():
+			mg.woke(timer.C())
 			mg.Banner("The auction is over!", 0)
-			time.Sleep(2000 * time.Millisecond)
+			mg.sleep(2000 * time.Millisecond)
 			return
 
-		case msg := <-mg.timerinfo:
-			av.PrintTime(msg)
-
-		case ev := <-mg.eventQueue:
-			if ev.Type != termbox.EventKey {
-				break
-			}
+		case a := <-mg.input(tick.C(), timer.C()):
+			mg.took(a)
+			p := av.humanPlayer(a)
 			switch {
-			case ev.Ch == pkeys[0]:
-				// Player 1 up
-				if mg.nplayers >= 1 {
-					av.newpos[0] = av.pos[0] + 1
-				}
-			case ev.Ch == pkeys[1]:
-				// Player 1 down
-				if mg.nplayers >= 1 {
-					av.newpos[0] = av.pos[0] - 1
-				}
-			case ev.Ch == pkeys[2]:
-				// Player 2 up
-				if mg.nplayers >= 2 {
-					av.newpos[1] = av.pos[1] + 1
-				}
-			case ev.Ch == pkeys[3]:
-				// Player 2 down
-				if mg.nplayers >= 2 {
-					av.newpos[1] = av.pos[1] - 1
-				}
-			case ev.Ch == pkeys[4]:
-				// Player 3 up
-				if mg.nplayers >= 3 {
-					av.newpos[2] = av.pos[2] + 1
-				}
-			case ev.Ch == pkeys[5]:
-				// Player 3 down
-				if mg.nplayers >= 3 {
-					av.newpos[2] = av.pos[2] - 1
-				}
-			case ev.Ch == pkeys[6]:
-				// Player 4 up
-				if mg.nplayers >= 4 {
-					av.newpos[3] = av.pos[3] + 1
-				}
-			case ev.Ch == pkeys[7]:
-				// Player 4 down
-				if mg.nplayers >= 4 {
-					av.newpos[3] = av.pos[3] - 1
-				}
-			case ev.Key == termbox.KeyBackspace2:
+			case a.Type == ActionAuctionUp && p >= 0:
+				av.input[p].press(1, mg.Clock.Now())
+			case a.Type == ActionAuctionDown && p >= 0:
+				av.input[p].press(-1, mg.Clock.Now())
+			case a.Type == ActionCollude && p >= 0 && md.level.collusion():
+				av.toggleCollusion(p)
+			case a.Type == ActionEndPhase && end.add(a):
 				mg.Banner("Auction ended early!", 0)
-				termbox.Flush()
-				time.Sleep(1 * time.Second)
+				mg.Renderer.Flush()
+				mg.sleep(1 * time.Second)
 				return
 			}
+			continue
+		}
+
+		copy(av.newpos, av.pos)
+		for p := 0; p < mg.nplayers; p++ {
+			if b := mg.Bidders[p]; b != nil {
+				av.newpos[p] = av.pos[p] + b.Bid(av, p)
+			} else {
+				av.newpos[p] = av.pos[p] + av.input[p].move(now)
+			}
 		}
 
 		av.clipPos(av.newpos)
 
 		// Find the new bar positions
+		// Colluding players trade among themselves, apart from the
+		// market
 		barposu := barmax
 		barposl := barmin
 		for k := 0; k < mg.nplayers; k++ {
+			if av.colluding[k] {
+				continue
+			}
 			if av.buySell[k] == seller {
 				if av.newpos[k] < barposu {
 					barposu = av.newpos[k]
@@ -750,6 +754,9 @@ func (av *AuctionView) RunAuction() {
 
 		// Move players back to their bar if they have passed it
 		for k := 0; k < mg.nplayers; k++ {
+			if av.colluding[k] {
+				continue
+			}
 			if av.buySell[k] == seller {
 				if av.newpos[k] < barposu {
 					av.newpos[k] = barposu
@@ -795,12 +802,32 @@ func (av *AuctionView) RunAuction() {
 			}
 		}
 
-		termbox.Flush()
-		time.Sleep(eventDelay)
+		if b, s := av.colluders(); b >= 0 {
+			colluden++
+			if colluden == transactDelay {
+				av.trade(b, s, av.posMoney(av.pos[b]))
+				colluden = 0
+				av.printPlayerAmounts()
+			}
+		}
+
+		mg.Renderer.Flush()
+	}
+}
+
+// toggleCollusion starts or stops player p trading privately with
+// another colluding player.
+func (av *AuctionView) toggleCollusion(p int) {
+	mg := av.mule
+	av.colluding[p] = !av.colluding[p]
+	txt := "       "
+	if av.colluding[p] {
+		txt = "Collude"
 	}
+	av.Print((p+1)*av.colw-3, mg.h-barmin+6, txt, mg.PlayerColors[p], ColorBlack)
 }
 
-func (av *AuctionView) Print(x, y int, msg string, fg, bg termbox.Attribute) {
+func (av *AuctionView) Print(x, y int, msg string, fg, bg Attribute) {
 	mg := av.mule
 	mg.PrintMain(ax0+x, ay0+y, msg, fg, bg)
 }
@@ -819,31 +846,37 @@ func (av *AuctionView) sellToStore() {
 				if py.Food <= py.requiredFood && !av.pastCritical[k] {
 					av.pos[k] = barmax
 					av.pastCritical[k] = true
+					av.input[k].release()
 				} else if py.Food > 0 {
 					py.Food--
 					py.money += av.minPrice
 					md.storeFood++
+					av.logTrade(-1, k, av.minPrice)
 				}
 			case energy:
 				if py.Energy <= py.requiredEnergy && !av.pastCritical[k] {
 					av.pos[k] = barmax
 					av.pastCritical[k] = true
+					av.input[k].release()
 				} else if py.Energy > 0 {
 					py.Energy--
 					py.money += av.minPrice
 					md.storeEnergy++
+					av.logTrade(-1, k, av.minPrice)
 				}
 			case smithore:
 				if py.Smithore > 0 {
 					py.Smithore--
 					py.money += av.minPrice
 					md.storeSmithore++
+					av.logTrade(-1, k, av.minPrice)
 				}
 			case crystite:
 				if py.Crystite > 0 {
 					py.Crystite--
 					py.money += av.minPrice
 					md.storeCrystite++
+					av.logTrade(-1, k, av.minPrice)
 				}
 			}
 		}
@@ -865,24 +898,28 @@ func (av *AuctionView) buyFromStore() {
 					py.Food++
 					md.storeFood--
 					py.money -= av.maxprice
+					av.logTrade(k, -1, av.maxprice)
 				}
 			case energy:
 				if md.storeEnergy > 0 {
 					py.Energy++
 					md.storeEnergy--
 					py.money -= av.maxprice
+					av.logTrade(k, -1, av.maxprice)
 				}
 			case smithore:
 				if md.storeSmithore > 0 {
 					py.Smithore++
 					md.storeSmithore--
 					py.money -= av.maxprice
+					av.logTrade(k, -1, av.maxprice)
 				}
 			case crystite:
 				if md.storeCrystite > 0 {
 					py.Crystite++
 					md.storeCrystite--
 					py.money -= av.maxprice
+					av.logTrade(k, -1, av.maxprice)
 				}
 			}
 		}
@@ -892,47 +929,81 @@ func (av *AuctionView) buyFromStore() {
 func (av *AuctionView) sellToPlayer() {
 
 	mg := av.mule
-	md := mg.Model
 
 	// Find a buyer/seller pair
 	// TODO should randomize order
 	var buyerp, sellerp int
 	for k := 0; k < mg.nplayers; k++ {
-		if av.buySell[k] == seller && av.pos[k] == av.barposl {
+		if av.buySell[k] == seller && av.pos[k] == av.barposl && !av.colluding[k] {
 			sellerp = k
 		}
 	}
 	for k := 0; k < mg.nplayers; k++ {
-		if av.buySell[k] == buyer && av.pos[k] == av.barposu {
+		if av.buySell[k] == buyer && av.pos[k] == av.barposu && !av.colluding[k] {
 			buyerp = k
 		}
 	}
 
+	av.trade(buyerp, sellerp, av.posMoney(av.barposu))
+}
+
+// colluders returns a colluding buyer and seller that have met on the
+// bar, or -1 if there are none.
+func (av *AuctionView) colluders() (int, int) {
+	mg := av.mule
+	for b := 0; b < mg.nplayers; b++ {
+		if av.buySell[b] != buyer || !av.colluding[b] || av.pos[b] < barmin {
+			continue
+		}
+		for s := 0; s < mg.nplayers; s++ {
+			if av.buySell[s] == seller && av.colluding[s] && av.pos[s] == av.pos[b] {
+				return b, s
+			}
+		}
+	}
+	return -1, -1
+}
+
+// logTrade logs the sale of one unit of the good being auctioned from
+// player sellerp to player buyerp, where -1 is the store.
+func (av *AuctionView) logTrade(buyerp, sellerp, price int) {
+	av.mule.logEvent(EventTrade, -1, "good", strings.ToLower(rtnames[av.aucType]),
+		"buyer", buyerp, "seller", sellerp, "price", price)
+}
+
+// trade sells one unit from player sellerp to player buyerp at price
+// amt.
+func (av *AuctionView) trade(buyerp, sellerp, amt int) {
+
+	mg := av.mule
+	md := mg.Model
+
 	pyb := md.Players[buyerp]
 	pys := md.Players[sellerp]
-	amt := av.posMoney(av.barposu)
 	switch av.aucType {
 	case food:
 		if pys.Food <= pys.requiredFood && !av.pastCritical[sellerp] {
 			av.pos[sellerp] = barmax
 			av.pastCritical[sellerp] = true
-			mg.drainQueue()
+			av.input[sellerp].release()
 		} else if pys.Food > 0 {
 			pyb.Food++
 			pys.Food--
 			pyb.money -= amt
 			pys.money += amt
+			av.logTrade(buyerp, sellerp, amt)
 		}
 	case energy:
 		if pys.Energy <= pys.requiredEnergy && !av.pastCritical[sellerp] {
 			av.pos[sellerp] = barmax
 			av.pastCritical[sellerp] = true
-			mg.drainQueue()
+			av.input[sellerp].release()
 		} else if pys.Energy > 0 {
 			pyb.Energy++
 			pys.Energy--
 			pyb.money -= amt
 			pys.money += amt
+			av.logTrade(buyerp, sellerp, amt)
 		}
 	case smithore:
 		if pys.Smithore > 0 {
@@ -940,6 +1011,7 @@ func (av *AuctionView) sellToPlayer() {
 			pys.Smithore--
 			pyb.money -= amt
 			pys.money += amt
+			av.logTrade(buyerp, sellerp, amt)
 		}
 	case crystite:
 		if pys.Crystite > 0 {
@@ -947,6 +1019,7 @@ func (av *AuctionView) sellToPlayer() {
 			pys.Crystite--
 			pyb.money -= amt
 			pys.money += amt
+			av.logTrade(buyerp, sellerp, amt)
 		}
 	}
 }
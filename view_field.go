@@ -2,21 +2,14 @@ package mule
 
 import (
 	"fmt"
-	"math/rand"
 	"strings"
 	"time"
-
-	"github.com/nsf/termbox-go"
 )
 
 // Colors
 const (
-	backgroundColor = termbox.ColorBlack
-	boardColor      = termbox.ColorBlack
-
-	// Offset for the MULE location within the plot
-	starH = 4
-	starV = 3
+	backgroundColor = ColorBlack
+	boardColor      = ColorBlack
 
 	animationSpeed = 100 * time.Millisecond
 
@@ -24,17 +17,16 @@ const (
 	mountainSymbol = "∧"
 )
 
+// Plot selection key for each player
 var (
-	selectKeys = []rune{'a', 'd', 'g', 'j'}
+	selectKeys = []rune{'a', 'd', 'g', 'j', 'l', 'z', 'c', 'm'}
 )
 
 type FieldView struct {
 	view
 
-	// Wumpus status
-	wumpusOut bool
-	wumpusx   int
-	wumpusy   int
+	// The wumpus of the current turn, nil outside of a turn
+	wumpus *wumpusHunt
 
 	// Random drift for the river
 	rd []int
@@ -45,20 +37,22 @@ type FieldView struct {
 
 func (fv *FieldView) Init() {
 
+	mg := fv.mule
 	fv.view_init()
 
-	// River drift positions
-	fv.rd = make([]int, nrow*ploth+1)
-	for i := ploth*nrow/2 - 2*ploth/3; i >= 0; i-- {
-		fv.rd[i] = fv.rd[i+1] + int(rand.Int63()%3-1)
+	// River drift positions, the river runs straight past the store
+	si, _ := mg.Model.storePlot()
+	fv.rd = make([]int, mg.Model.nrow*mg.ploth+1)
+	for i := si*mg.ploth - 1; i >= 0; i-- {
+		fv.rd[i] = fv.rd[i+1] + int(fv.mule.Model.rng.Int63()%3-1)
 		if fv.rd[i] > 3 {
 			fv.rd[i] = 3
 		} else if fv.rd[i] < -3 {
 			fv.rd[i] = -3
 		}
 	}
-	for i := ploth*(nrow/2+1) + 2*nrow/3; i < len(fv.rd); i++ {
-		fv.rd[i] = fv.rd[i-1] + int(rand.Int63()%3-1)
+	for i := (si + 1) * mg.ploth; i < len(fv.rd); i++ {
+		fv.rd[i] = fv.rd[i-1] + int(fv.mule.Model.rng.Int63()%3-1)
 		if fv.rd[i] > 3 {
 			fv.rd[i] = 3
 		} else if fv.rd[i] < -3 {
@@ -74,65 +68,98 @@ func NewFieldView() *FieldView {
 
 func (fv *FieldView) DrawLandscape() {
 
-	fg := termbox.ColorWhite
-	bg := termbox.ColorBlue
+	fg := ColorWhite
+	bg := ColorBlue
 
-	// The river
-	xm := ncol * plotw / 2
-	for y := 0; y < nrow*ploth; y++ {
-		x := fv.rd[y] + xm - 2
-		fv.Print(x, y, "~~~~", fg, bg, true, false)
+	// The river, which drifts within its plots
+	mg := fv.mule
+	md := mg.Model
+	for _, plt := range md.plots {
+		if !plt.River {
+			continue
+		}
+		// The drift may not take the river past the plot's edges,
+		// which may be the edges of the field
+		xm := plt.Col*mg.plotw + mg.plotw/2
+		lo := plt.Col*mg.plotw + 1
+		hi := (plt.Col+1)*mg.plotw - 4
+		for y := plt.Row * mg.ploth; y < (plt.Row+1)*mg.ploth; y++ {
+			x := fv.rd[y] + xm - 2
+			if x > hi {
+				x = hi
+			}
+			if x < lo {
+				x = lo
+			}
+			fv.Print(x, y, "~~~~", fg, bg, true, false)
+		}
 	}
 
 	// The store
-	fv.FillPlot(nrow/2, ncol/2, 'O', termbox.ColorWhite, boardColor)
+	si, sj := md.storePlot()
+	fv.FillPlot(si, sj, 'O', ColorWhite, boardColor)
 
 	// The mountains
-	for i := 0; i < nrow; i++ {
-		for j := 0; j < ncol; j++ {
+	for i := 0; i < mg.Model.nrow; i++ {
+		for j := 0; j < mg.Model.ncol; j++ {
 			lev := fv.mule.Model.GetPlot(i, j).Mountains
 			if lev == 0 {
 				continue
 			}
 			s := strings.Repeat(mountainSymbol, lev)
-			x := j*plotw + 1
-			y := i*ploth + 1
-			fv.Print(x, y, s, termbox.ColorWhite|termbox.AttrBold, termbox.ColorBlack, true, false)
+			x := j*mg.plotw + 1
+			y := i*mg.ploth + 1
+			fv.Print(x, y, s, ColorWhite|AttrBold, ColorBlack, true, false)
 		}
 	}
 }
 
 func (fv *FieldView) ShowProduction() {
 	mg := fv.mule
-	for i := 0; i < nrow; i++ {
-		for j := 0; j < ncol; j++ {
+	for i := 0; i < mg.Model.nrow; i++ {
+		for j := 0; j < mg.Model.ncol; j++ {
 			plt := fv.mule.Model.GetPlot(i, j)
 			if !plt.Owned {
 				continue
 			}
 			pcol := fv.mule.PlayerColors[plt.Owner]
 
-			x0 := j * plotw
-			y0 := i * ploth
+			x0 := j * mg.plotw
+			y0 := i * mg.ploth
 			qm := fmt.Sprintf("%d", plt.Production)
+
+			// Mark the bonuses: learning curve, economies of scale
+			// and adjacency
+			if plt.bonuses&bonusLearning != 0 {
+				qm += "L"
+			}
+			if plt.bonuses&bonusScale != 0 {
+				qm += "S"
+			}
+			if plt.bonuses&bonusAdjacent != 0 {
+				qm += "A"
+			}
 			x := x0 + 1
-			y := y0 + ploth - 2
+			y := y0 + mg.ploth - 2
 			ii := y*mg.w + x
 			bg := fv.backing_bg[ii]
-			fv.Print(x, y, qm, pcol|termbox.AttrReverse, bg, false, false)
+			fv.Print(x, y, qm, pcol^AttrReverse, bg, false, false)
 		}
 	}
 }
 
 func (fv *FieldView) PlayerTurn(p, r int, side location) (bool, location) {
 
+	mg := fv.mule
+
 	// Start at either the right or left side of the store
-	fv.ypos = ploth * nrow / 2
+	si, sj := mg.Model.storePlot()
+	fv.ypos = si*mg.ploth + mg.ploth/2
 	if side == locStoreLeft {
-		fv.xpos = plotw*ncol/2 - plotw/2 - 1
+		fv.xpos = sj*mg.plotw - 1
 		fv.xposq = []int{fv.xpos, -1, -1}
 	} else if side == locStoreRight {
-		fv.xpos = plotw*ncol/2 + plotw/2 + 1
+		fv.xpos = (sj + 1) * mg.plotw
 		fv.xposq = []int{fv.xpos, -1, -1}
 	} else {
 		panic("Invalid store location code\n")
@@ -143,7 +170,7 @@ func (fv *FieldView) PlayerTurn(p, r int, side location) (bool, location) {
 	fv.DrawPlayer(p, fv.xpos, fv.ypos)
 	fv.DrawLandscape()
 	fv.DrawOwnedPlots()
-	termbox.Flush()
+	fv.mule.Renderer.Flush()
 
 	// Reference to the player, needs to be a reference as we will
 	// mutate it below
@@ -155,8 +182,13 @@ func (fv *FieldView) PlayerTurn(p, r int, side location) (bool, location) {
 	// Location handler
 	lh := func(v *view, x, y int) location {
 
-		i := v.ypos / ploth
-		j := v.xpos / plotw
+		// The rows below the plots are off limits
+		if y >= mg.Model.nrow*mg.ploth {
+			return locStoreBlocked
+		}
+
+		i := v.ypos / mg.ploth
+		j := v.xpos / mg.plotw
 
 		// Update the speed delay parameter
 		plt := v.mule.Model.GetPlot(i, j)
@@ -166,23 +198,22 @@ func (fv *FieldView) PlayerTurn(p, r int, side location) (bool, location) {
 		}
 
 		// Check the wumpus
-		if fv.wumpusOut && (v.ypos == fv.wumpusy) && (v.xpos == fv.wumpusx) {
-			amt := 100 * (r + 4) / 4
-			mg := fmt.Sprintf("You caught the wumpus and earned $%d!", amt)
-			fv.wumpusOut = false
+		if wh := fv.wumpus; wh.out && (v.ypos == wh.y) && (v.xpos == wh.x) {
+			amt := mg.Model.rules.wumpusReward(r)
+			msg := fmt.Sprintf("You caught the wumpus and earned $%d!", amt)
+			wh.out = false
 			fv.RestorePoint(v.xpos, v.ypos)
 			py.money += amt
-			fv.Banner([]string{mg}, termbox.ColorWhite, termbox.ColorBlack)
-			termbox.Flush()
+			mg.logEvent(EventWumpus, p, "money", amt)
+			fv.Banner([]string{msg}, ColorWhite, ColorBlack)
+			fv.mule.Renderer.Flush()
 		}
 
 		// Check if we are entering the store
-		i0 := nrow / 2
-		y0 := i0 * ploth
-		y1 := y0 + ploth
-		j0 := ncol / 2
-		x0 := j0 * plotw
-		x1 := x0 + plotw
+		y0 := si * mg.ploth
+		y1 := y0 + mg.ploth
+		x0 := sj * mg.plotw
+		x1 := x0 + mg.plotw
 		if y > y0 && y < y1-1 {
 			if x == x0 {
 				return locStoreLeft
@@ -194,10 +225,25 @@ func (fv *FieldView) PlayerTurn(p, r int, side location) (bool, location) {
 		return locStoreNone
 	}
 
-	// Key handler. Spacebar installs/releases mule.
-	kh := func(v *view, ev termbox.Event) continueType {
+	// Key handler. Spacebar installs/releases mule, 's' puts the
+	// plot up for sale.
+	kh := func(v *view, a Action) continueType {
+
+		if a.Type == ActionSellPlot {
+			pl := fv.mule.Model.GetPlot(fv.ypos/mg.ploth, fv.xpos/mg.plotw)
+			if pl.Owned && pl.Owner == p {
+				pl.ForSale = !pl.ForSale
+				msg := "This plot will be sold at the next land auction"
+				if !pl.ForSale {
+					msg = "This plot is no longer for sale"
+				}
+				fv.Banner([]string{msg}, ColorWhite, boardColor)
+				fv.mule.Renderer.Flush()
+			}
+			return continueTypeStay
+		}
 
-		if ev.Key != termbox.KeySpace {
+		if a.Type != ActionConfirm {
 			return continueTypeStay
 		}
 		if !py.hasMule {
@@ -209,19 +255,23 @@ func (fv *FieldView) PlayerTurn(p, r int, side location) (bool, location) {
 		ym := fv.yposq[(fv.iq+1)%fv.iql]
 
 		// Position of Mule within plot
-		xr := xm % plotw
-		yr := ym % ploth
+		xr := xm % mg.plotw
+		yr := ym % mg.ploth
 
 		// In correct position to install Mule
-		if xr == starH && yr == starV {
+		if xr == mg.starH && yr == mg.starV {
 
 			// Plot we are trying to install the Mule on
-			j := xm / plotw
-			i := ym / ploth
+			j := xm / mg.plotw
+			i := ym / mg.ploth
 			pl := fv.mule.Model.GetPlot(i, j)
 
 			if pl.Owned && pl.Owner == p {
 				pl.MuleStatus = py.muleOutfitType
+				if pl.MuleStatus == outfitAuto {
+					pl.MuleStatus = pl.autoOutfit()
+				}
+				mg.logEvent(EventMuleInstalled, p, "row", i, "col", j, "good", goodName(pl.MuleStatus))
 				py.hasMule = false
 				py.muleOutfitType = outfitNone
 				fv.RemoveMule(prc)
@@ -229,49 +279,54 @@ func (fv *FieldView) PlayerTurn(p, r int, side location) (bool, location) {
 
 				// Move the player next to the mule
 				fv.RestorePoint(fv.xpos, fv.ypos)
-				fv.ypos = i*ploth + starV
-				fv.xpos = j*plotw + starH + 1
+				fv.ypos = i*mg.ploth + mg.starV
+				fv.xpos = j*mg.plotw + mg.starH + 1
 				fv.iq = 0
 				fv.xposq = []int{fv.xpos, -1, -1}
 				fv.yposq = []int{fv.ypos, -1, -1}
 				fv.DrawPlayer(p, fv.xpos, fv.ypos)
 
-				fv.Banner([]string{"MULE successfully installed"}, termbox.ColorWhite, boardColor)
+				fv.Banner([]string{"MULE successfully installed"}, ColorWhite, boardColor)
 				fv.DrawOwnedPlots()
-				termbox.Flush()
+				fv.mule.Renderer.Flush()
 				return continueTypeStay
 			}
 		}
 
 		// Mule escapes
+		mg.logEvent(EventMuleEscaped, p, "good", goodName(py.muleOutfitType))
 		py.hasMule = false
 		py.muleOutfitType = outfitNone
 		msg := []string{"Your MULE escaped!"}
-		fv.Banner(msg, termbox.ColorWhite, termbox.ColorBlack)
+		fv.Banner(msg, ColorWhite, ColorBlack)
 		fv.RemoveMule(prc)
-		termbox.Flush()
+		fv.mule.Renderer.Flush()
 		return continueTypeStay
 	}
 
+	// The wumpus hunt lasts until we leave the field
+	fv.startWumpus()
+	defer fv.stopWumpus()
+
 	// Play until we enter the store
 	for {
-		c := fv.turn(p, 'Y', prc, lh, kh)
+		c := fv.turn(p, 'Y', prc, lh, kh, fv.wumpus, fv.moveWumpus)
 
 		switch c {
 		case locTimeout:
 			py.hasMule = false
 			py.muleOutfitType = outfitNone
 			msg := []string{"You are out of time!"}
-			fv.Banner(msg, termbox.ColorWhite, termbox.ColorBlack)
-			termbox.Flush()
-			time.Sleep(3000 * time.Millisecond)
+			fv.Banner(msg, ColorWhite, ColorBlack)
+			fv.mule.Renderer.Flush()
+			mg.sleep(3000 * time.Millisecond)
 			return false, locStoreNone
 		case locStoreLeft:
 			return true, locStoreLeft
 		case locStoreRight:
 			return true, locStoreRight
 		case locStoreNone:
-			time.Sleep(1000 * time.Millisecond)
+			mg.sleep(1000 * time.Millisecond)
 		default:
 			panic("Invalid store location code in field\n")
 		}
@@ -282,10 +337,10 @@ func (fv *FieldView) PlayerTurn(p, r int, side location) (bool, location) {
 
 func (fv *FieldView) drawPlotIcon(plt *Plot) {
 	mg := fv.mule
-	x := plt.Col*plotw + starH
-	y := plt.Row*ploth + starV
+	x := plt.Col*mg.plotw + mg.starH
+	y := plt.Row*mg.ploth + mg.starV
 	ii := y*mg.w + x
-	col := termbox.ColorWhite | termbox.AttrBold
+	col := ColorWhite | AttrBold
 	if plt.MuleStatus == outfitNone {
 		fv.Print(x, y, homeSymbol, col, fv.backing_bg[ii], true, false)
 	} else {
@@ -296,8 +351,9 @@ func (fv *FieldView) drawPlotIcon(plt *Plot) {
 
 func (fv *FieldView) DrawOwnedPlots() {
 
-	for i := 0; i < nrow; i++ {
-		for j := 0; j < ncol; j++ {
+	mg := fv.mule
+	for i := 0; i < mg.Model.nrow; i++ {
+		for j := 0; j < mg.Model.ncol; j++ {
 
 			plt := fv.mule.Model.GetPlot(i, j)
 			if !plt.Owned {
@@ -311,14 +367,16 @@ func (fv *FieldView) DrawOwnedPlots() {
 	}
 }
 
-func (fv *FieldView) FillPlot(i, j int, c rune, fg, bg termbox.Attribute) {
+func (fv *FieldView) FillPlot(i, j int, c rune, fg, bg Attribute) {
+
+	mg := fv.mule
 
 	// upper/left corner of plot
-	x0 := j * plotw
-	y0 := i * ploth
+	x0 := j * mg.plotw
+	y0 := i * mg.ploth
 
-	for i := 0; i < ploth; i++ {
-		for j := 0; j < plotw; j++ {
+	for i := 0; i < mg.ploth; i++ {
+		for j := 0; j < mg.plotw; j++ {
 			x := x0 + j
 			y := y0 + i
 			fv.Print(x, y, string(c), fg, bg, true, true)
@@ -326,22 +384,22 @@ func (fv *FieldView) FillPlot(i, j int, c rune, fg, bg termbox.Attribute) {
 	}
 }
 
-func (fv *FieldView) HighlightPlot(i, j int, c rune, fg termbox.Attribute, add bool) {
+func (fv *FieldView) HighlightPlot(i, j int, c rune, fg Attribute, add bool) {
 
 	mg := fv.mule
 
 	// upper/left corner of plot
-	x0 := j * plotw
-	y0 := i * ploth
+	x0 := j * mg.plotw
+	y0 := i * mg.ploth
 
 	// Top/bottom sides
-	for d := 0; d < ploth; d += ploth - 1 {
-		for k := 0; k < plotw; k++ {
+	for d := 0; d < mg.ploth; d += mg.ploth - 1 {
+		for k := 0; k < mg.plotw; k++ {
 			x := x0 + k
 			y := y0 + d
 			ii := y*mg.w + x
 			bg := fv.backing_bg[ii]
-			termbox.SetCell(mg.x0+x, mg.y0+y, c, fg, bg)
+			mg.Renderer.SetCell(mg.x0+x, mg.y0+y, c, fg, bg)
 			if add {
 				fv.backing_rune[ii] = c
 				fv.backing_fg[ii] = fg
@@ -350,13 +408,13 @@ func (fv *FieldView) HighlightPlot(i, j int, c rune, fg termbox.Attribute, add b
 	}
 
 	// Left/right sides
-	for d := 0; d < plotw; d += plotw - 1 {
-		for k := 0; k < ploth; k++ {
+	for d := 0; d < mg.plotw; d += mg.plotw - 1 {
+		for k := 0; k < mg.ploth; k++ {
 			x := x0 + d
 			y := y0 + k
 			ii := y*mg.w + x
 			bg := fv.backing_bg[ii]
-			termbox.SetCell(mg.x0+x, mg.y0+y, c, fg, bg)
+			mg.Renderer.SetCell(mg.x0+x, mg.y0+y, c, fg, bg)
 			if add {
 				fv.backing_rune[ii] = c
 				fv.backing_fg[ii] = fg
@@ -370,59 +428,63 @@ func (fv *FieldView) RestoreHighlightedPlot(i, j int) {
 	mg := fv.mule
 
 	// upper/left corner of plot
-	x0 := j * plotw
-	y0 := i * ploth
+	x0 := j * mg.plotw
+	y0 := i * mg.ploth
 
 	// Top/bottom sides
-	for d := 0; d < ploth; d += ploth - 1 {
-		for k := 0; k < plotw; k++ {
+	for d := 0; d < mg.ploth; d += mg.ploth - 1 {
+		for k := 0; k < mg.plotw; k++ {
 			x := x0 + k
 			y := y0 + d
 			ii := y*mg.w + x
 			c := fv.backing_rune[ii]
 			fg := fv.backing_fg[ii]
 			bg := fv.backing_bg[ii]
-			termbox.SetCell(mg.x0+x, mg.y0+y, c, fg, bg)
+			mg.Renderer.SetCell(mg.x0+x, mg.y0+y, c, fg, bg)
 		}
 	}
 
 	// Left/right sides
-	for d := 0; d < plotw; d += plotw - 1 {
-		for k := 0; k < ploth; k++ {
+	for d := 0; d < mg.plotw; d += mg.plotw - 1 {
+		for k := 0; k < mg.ploth; k++ {
 			x := x0 + d
 			y := y0 + k
 			ii := y*mg.w + x
 			c := fv.backing_rune[ii]
 			fg := fv.backing_fg[ii]
 			bg := fv.backing_bg[ii]
-			termbox.SetCell(mg.x0+x, mg.y0+y, c, fg, bg)
+			mg.Renderer.SetCell(mg.x0+x, mg.y0+y, c, fg, bg)
 		}
 	}
 }
 
-func (fv *FieldView) selectHit() (bool, int) {
-	// Break the highlight time into 5 segments
-	for k := 0; k < 5; k++ {
+// selectHit waits for a human player who hasn't claimed a plot yet
+// to claim the highlighted one.  Other claims are ignored.
+func (fv *FieldView) selectHit(selected []bool) (bool, int) {
+	// The plot is highlighted for 5 animation steps
+	timer := fv.mule.Clock.NewTimer(5 * animationSpeed)
+	defer timer.Stop()
+	for {
 		select {
-		case ev := <-fv.mule.eventQueue:
-			if ev.Type == termbox.EventKey {
-				for j := 0; j < fv.mule.nplayers; j++ {
-					if ev.Ch == selectKeys[j] {
-						return true, j
-					}
+		case a := <-fv.mule.input(timer.C()):
+			fv.mule.took(a)
+			if a.Type == ActionClaimPlot {
+				p := a.player(fv.mule.nplayers)
+				if p >= 0 && !selected[p] && fv.mule.PlotChoosers[p] == nil {
+					return true, p
 				}
 			}
-		default:
-			time.Sleep(animationSpeed)
+		case <-timer.C():
+			fv.mule.woke(timer.C())
+			return false, -1
 		}
 	}
-	return false, -1
 }
 
 func (fv *FieldView) FlashPlot(i, j, n int) {
 
 	plt := fv.mule.Model.GetPlot(i, j)
-	col := termbox.ColorWhite
+	col := ColorWhite
 	if plt.Owned {
 		col = fv.mule.PlayerColors[plt.Owner]
 	}
@@ -430,21 +492,22 @@ func (fv *FieldView) FlashPlot(i, j, n int) {
 		if k%2 == 0 {
 			fv.HighlightPlot(i, j, 'X', col, false)
 		} else {
-			fv.HighlightPlot(i, j, 'X', termbox.ColorBlack, false)
+			fv.HighlightPlot(i, j, 'X', ColorBlack, false)
 		}
-		termbox.Flush()
-		time.Sleep(time.Second)
+		fv.mule.Renderer.Flush()
+		fv.mule.sleep(time.Second)
 	}
 }
 
 func (fv *FieldView) FlashRow(row int) {
 
-	for j := 0; j < ncol; j++ {
-		fv.HighlightPlot(row, j, 'X', termbox.ColorCyan, false)
-		termbox.Flush()
-		time.Sleep(time.Second)
+	mg := fv.mule
+	for j := 0; j < mg.Model.ncol; j++ {
+		fv.HighlightPlot(row, j, 'X', ColorCyan, false)
+		fv.mule.Renderer.Flush()
+		mg.sleep(time.Second)
 		fv.RestoreHighlightedPlot(row, j)
-		termbox.Flush()
+		fv.mule.Renderer.Flush()
 	}
 }
 
@@ -454,24 +517,37 @@ func (fv *FieldView) SelectPlot(r int) {
 	fv.DrawLandscape()
 	fv.DrawOwnedPlots()
 
-	fg := termbox.ColorWhite
+	fg := ColorWhite
 
 	msg := make([]string, 2)
 	msg[0] = fmt.Sprintf("Select plots for round %d, press space to start.\n", r+1)
 	var w []string
 	for j, na := range fv.mule.PlayerNames {
-		w = append(w, fmt.Sprintf("%s %q", na, selectKeys[j]))
+		if mg.PlotChoosers[j] != nil {
+			w = append(w, fmt.Sprintf("%s (computer)", na))
+		} else {
+			w = append(w, fmt.Sprintf("%s %q", na, selectKeys[j]))
+		}
 	}
 	msg[1] = "Player keys: " + strings.Join(w, ", ")
 
 	fv.Banner(msg, fg, boardColor)
-	termbox.Flush()
-	fv.mule.WaitForSpace()
+	mg.Renderer.Flush()
+	fv.mule.WaitForSpace(AnyPlayer)
+
+	// Number of unowned plots that will be highlighted
+	remaining := 0
+	for _, pl := range mg.Model.plots {
+		if !pl.Owned && !mg.Model.isStore(pl.Row, pl.Col) {
+			remaining++
+		}
+	}
 
-	selected := make([]bool, 4)
+	mg.startPhase()
+	selected := make([]bool, mg.nplayers)
 	nSelected := 0
-	for i := 0; i < nrow; i++ {
-		for j := 0; j < ncol; j++ {
+	for i := 0; i < mg.Model.nrow; i++ {
+		for j := 0; j < mg.Model.ncol; j++ {
 			pl := fv.mule.Model.GetPlot(i, j)
 			if nSelected == fv.mule.nplayers {
 				return
@@ -483,22 +559,33 @@ func (fv *FieldView) SelectPlot(r int) {
 			}
 
 			// Skip over the store
-			if i == nrow/2 && j == ncol/2 {
+			if mg.Model.isStore(i, j) {
 				continue
 			}
 
 			fv.DrawLandscape()
 			fv.DrawOwnedPlots()
-			fv.HighlightPlot(i, j, 'X', termbox.ColorWhite, false)
-			termbox.Flush()
+			fv.HighlightPlot(i, j, 'X', ColorWhite, false)
+			mg.Renderer.Flush()
+
+			// Human players get the first chance at the plot,
+			// then the computer players are asked in turn
+			hit, p := fv.selectHit(selected)
+			for q := 0; !hit && q < mg.nplayers; q++ {
+				pc := mg.PlotChoosers[q]
+				if pc != nil && !selected[q] && pc.ClaimPlot(mg.Model, q, pl, remaining) {
+					hit, p = true, q
+				}
+			}
+			remaining--
 
-			hit, p := fv.selectHit()
 			if hit && !selected[p] {
 				pl.Owned = true
 				pl.Owner = p
 				selected[p] = true
 				nSelected++
-				time.Sleep(100 * time.Millisecond)
+				mg.logEvent(EventPlotClaimed, p, "row", i, "col", j)
+				mg.sleep(100 * time.Millisecond)
 				mg.drainQueue()
 			}
 			fv.HighlightPlot(i, j, ' ', boardColor, false)
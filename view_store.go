@@ -3,8 +3,6 @@ package mule
 import (
 	"fmt"
 	"time"
-
-	"github.com/nsf/termbox-go"
 )
 
 const (
@@ -45,8 +43,8 @@ func (sv *StoreView) Init() {
 
 func (sv *StoreView) DrawStore() {
 
-	fg := termbox.ColorWhite
-	bg := termbox.ColorBlack
+	fg := ColorWhite
+	bg := ColorBlack
 
 	sv.DrawHline(sx0, sx0+storeWidth, sy0, 'X', fg, bg)
 	sv.DrawHline(sx0, sx0+storeWidth-10, sy0+2, '-', fg, bg)
@@ -73,16 +71,17 @@ func (sv *StoreView) DrawStore() {
 
 	sv.Print(sx0+4, assay_y, "Assay office", fg, bg, true, false)
 
-	msg := fmt.Sprintf("Outfit MULE for crystite ($%d)", crystiteOutfitCost)
+	md := sv.mule.Model
+	msg := fmt.Sprintf("Outfit MULE for crystite ($%d)", md.outfitCost(outfitCrystite))
 	sv.Print(sx0+4, crystite_y, msg, fg, bg, true, false)
 
-	msg = fmt.Sprintf("Outfit MULE for smithore ($%d)", smithoreOutfitCost)
+	msg = fmt.Sprintf("Outfit MULE for smithore ($%d)", md.outfitCost(outfitSmithore))
 	sv.Print(sx0+4, smithore_y, msg, fg, bg, true, false)
 
-	msg = fmt.Sprintf("Outfit MULE for energy ($%d)", energyOutfitCost)
+	msg = fmt.Sprintf("Outfit MULE for energy ($%d)", md.outfitCost(outfitEnergy))
 	sv.Print(sx0+4, energy_y, msg, fg, bg, true, false)
 
-	msg = fmt.Sprintf("Outfit MULE for food ($%d)", foodOutfitCost)
+	msg = fmt.Sprintf("Outfit MULE for food ($%d)", md.outfitCost(outfitFood))
 	sv.Print(sx0+4, food_y, msg, fg, bg, true, false)
 
 	msg = "Pub"
@@ -94,7 +93,7 @@ func (sv *StoreView) DrawStore() {
 
 func (sv *StoreView) initLive(p int, side location) {
 
-	bg := termbox.ColorBlack
+	bg := ColorBlack
 
 	// Start at either the right or left side of the store,
 	// default to left side for first move.
@@ -113,7 +112,7 @@ func (sv *StoreView) initLive(p int, side location) {
 	sv.iq = 0
 
 	sv.DrawStore()
-	termbox.Flush()
+	sv.mule.Renderer.Flush()
 }
 
 // Too big, needs refactoring
@@ -129,8 +128,8 @@ func (sv *StoreView) PlayerTurn(p, r int, first bool, side location) (bool, loca
 		if y > 17 && y < mg.h && ((x <= sx0) || (x >= storeWidth+sx0)) {
 			if py.hasMule && py.muleOutfitType == outfitNone {
 				msg := []string{"Can't leave store with a MULE that is not outfitted"}
-				sv.Banner(msg, termbox.ColorWhite, termbox.ColorBlack)
-				termbox.Flush()
+				sv.Banner(msg, ColorWhite, ColorBlack)
+				mg.Renderer.Flush()
 				return locStoreBlocked
 			}
 		}
@@ -178,7 +177,7 @@ func (sv *StoreView) PlayerTurn(p, r int, first bool, side location) (bool, loca
 		return locStoreNone
 	}
 
-	kh := func(v *view, ev termbox.Event) continueType {
+	kh := func(v *view, a Action) continueType {
 		return continueTypeStay
 	}
 
@@ -186,7 +185,7 @@ func (sv *StoreView) PlayerTurn(p, r int, first bool, side location) (bool, loca
 
 	// Eventloop
 	for {
-		loc := sv.turn(p, 'Y', prc, lh, kh)
+		loc := sv.turn(p, 'Y', prc, lh, kh, nil, nil)
 
 		switch {
 		case loc == locTimeout:
@@ -197,9 +196,9 @@ func (sv *StoreView) PlayerTurn(p, r int, first bool, side location) (bool, loca
 			msg := []string{mg}
 			py.hasMule = false
 			py.muleOutfitType = outfitNone
-			sv.Banner(msg, termbox.ColorWhite, termbox.ColorBlack)
-			termbox.Flush()
-			time.Sleep(3000 * time.Millisecond)
+			sv.Banner(msg, ColorWhite, ColorBlack)
+			sv.mule.Renderer.Flush()
+			sv.mule.sleep(3000 * time.Millisecond)
 			return false, locStoreNone
 
 		case loc == locStoreLeft:
@@ -214,25 +213,25 @@ func (sv *StoreView) PlayerTurn(p, r int, first bool, side location) (bool, loca
 			switch b {
 			case buyResultNomules:
 				msg := []string{"The store has no mules"}
-				sv.Banner(msg, termbox.ColorWhite, termbox.ColorBlack)
-				termbox.Flush()
+				sv.Banner(msg, ColorWhite, ColorBlack)
+				mg.Renderer.Flush()
 			case buyResultNomoney:
 				msg := []string{"You do not have enuogh money to buy a mule"}
-				sv.Banner(msg, termbox.ColorWhite, termbox.ColorBlack)
-				termbox.Flush()
+				sv.Banner(msg, ColorWhite, ColorBlack)
+				mg.Renderer.Flush()
 			case buyResultReturned:
 				msg := []string{"You returned your MULE to the store"}
-				sv.Banner(msg, termbox.ColorWhite, termbox.ColorBlack)
+				sv.Banner(msg, ColorWhite, ColorBlack)
 				sv.RemoveMule(prc)
 				sv.xposq = []int{sv.xpos, -1, -1}
 				sv.yposq = []int{sv.ypos, -1, -1}
 				sv.iq = 0
 				sv.DrawStore()        // update mule count
 				mg.updateStatusBar(p) // update money
-				termbox.Flush()
+				mg.Renderer.Flush()
 			case buyResultSuccess:
 				msg := []string{"You bought a mule"}
-				sv.Banner(msg, termbox.ColorWhite, termbox.ColorBlack)
+				sv.Banner(msg, ColorWhite, ColorBlack)
 				sv.xposq = []int{sv.xpos, sv.xpos - 2, sv.xpos - 1}
 				sv.yposq = []int{mules_y, mules_y, mules_y}
 				sv.iq = 0
@@ -241,14 +240,16 @@ func (sv *StoreView) PlayerTurn(p, r int, first bool, side location) (bool, loca
 				sv.DrawMule(p)
 				sv.DrawStore()        // update mule count
 				mg.updateStatusBar(p) // update money
-				termbox.Flush()
+				mg.Renderer.Flush()
 			default:
 				panic("Invalid store location code in store\n")
 			}
 		case loc == locStoreAssay:
-			if mg.hasAssay {
-				i := mg.assay_y / ploth
-				j := mg.assay_x / plotw
+			if !mg.Model.level.crystite() {
+				mg.Banner("There is no crystite on this planet", 0)
+			} else if mg.hasAssay {
+				i := mg.assay_y / mg.ploth
+				j := mg.assay_x / mg.plotw
 				plt := mg.Model.GetPlot(i, j)
 				var msg string
 				switch plt.Crystite {
@@ -263,7 +264,7 @@ func (sv *StoreView) PlayerTurn(p, r int, first bool, side location) (bool, loca
 				case 4:
 					msg = "Crystite level is very high"
 				}
-				mg.Banner(msg, 0)
+				mg.SecretBanner(msg, "The assay result is in", 0)
 				mg.hasAssay = false
 			} else {
 				mg.hasAssay = true
@@ -278,24 +279,28 @@ func (sv *StoreView) PlayerTurn(p, r int, first bool, side location) (bool, loca
 			switch b {
 			case outfitResultNomule:
 				msg := []string{"You don't have a MULE"}
-				sv.Banner(msg, termbox.ColorWhite, termbox.ColorBlack)
-				termbox.Flush()
+				sv.Banner(msg, ColorWhite, ColorBlack)
+				mg.Renderer.Flush()
 			case outfitResultNomoney:
 				msg := []string{fmt.Sprintf("You don't have enough money to outfit a MULE for %s", oname)}
-				termbox.Flush()
-				sv.Banner(msg, termbox.ColorWhite, termbox.ColorBlack)
+				mg.Renderer.Flush()
+				sv.Banner(msg, ColorWhite, ColorBlack)
 			case outfitResultAlreadyOutfitted:
 				msg := []string{fmt.Sprintf("Your MULE is already outfitted for %s", oname)}
-				termbox.Flush()
-				sv.Banner(msg, termbox.ColorWhite, termbox.ColorBlack)
+				mg.Renderer.Flush()
+				sv.Banner(msg, ColorWhite, ColorBlack)
+			case outfitResultUnavailable:
+				msg := []string{"There is no crystite on this planet"}
+				sv.Banner(msg, ColorWhite, ColorBlack)
+				mg.Renderer.Flush()
 			case outfitResultSuccess:
 				msg := []string{fmt.Sprintf("Your MULE has been outfitted for %s", oname)}
-				sv.Banner(msg, termbox.ColorWhite, termbox.ColorBlack)
+				sv.Banner(msg, ColorWhite, ColorBlack)
 				py.muleSymbol = osy
 				py.muleOutfitType = otp
 				sv.DrawMule(p)
 				mg.updateStatusBar(p)
-				termbox.Flush()
+				mg.Renderer.Flush()
 			default:
 				panic("Invalid code in outfit\n")
 			}
@@ -304,17 +309,17 @@ func (sv *StoreView) PlayerTurn(p, r int, first bool, side location) (bool, loca
 			switch {
 			case b == pubResultNoMules:
 				msg := []string{fmt.Sprintf("No MULEs allowed in the pub")}
-				sv.Banner(msg, termbox.ColorWhite, termbox.ColorBlack)
-				termbox.Flush()
+				sv.Banner(msg, ColorWhite, ColorBlack)
+				mg.Renderer.Flush()
 			case b == pubResultSuccess:
-				mg.ClearTimers()
+				mg.stopTurnTimer()
 				msg := []string{fmt.Sprintf("You won $%d gambling!", amt)}
 				mg.updateStatusBar(p)
-				sv.Print(sv.xpos, sv.ypos, "\u263A", mg.PlayerColors[p], termbox.ColorBlack,
+				sv.Print(sv.xpos, sv.ypos, "\u263A", mg.PlayerColors[p], ColorBlack,
 					false, false)
-				sv.Banner(msg, termbox.ColorWhite, termbox.ColorBlack)
-				termbox.Flush()
-				time.Sleep(4000 * time.Millisecond)
+				sv.Banner(msg, ColorWhite, ColorBlack)
+				mg.Renderer.Flush()
+				mg.sleep(4000 * time.Millisecond)
 				return false, locStoreNone
 			default:
 				panic("Invalid code in pub")
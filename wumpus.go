@@ -5,48 +5,114 @@ import (
 	"time"
 )
 
-type wumpusInfo struct {
-	x      int
-	y      int
-	active bool
+// wumpusHunt is the wumpus of the current turn in the field.  It
+// hides in the mountains and shows itself every now and then, moving
+// each time its timer goes off in the turn's event loop.
+type wumpusHunt struct {
+	// The hunt has its own random number generator, so that the
+	// game's doesn't depend on when the wumpus moves
+	rng *rand.Rand
+
+	// The columns and rows of the plots with mountains
+	xv []int
+	yv []int
+
+	// Goes off at the wumpus' next move, nil if it has nowhere to
+	// hide
+	timer Timer
+
+	// The wumpus is showing, and out if it can be caught there
+	showing bool
+	out     bool
+	x       int
+	y       int
 }
 
-func wumpusManager(mg *MULE) {
+// startWumpus starts a hunt for the turn in the field that is about to
+// begin.  It must be stopped with stopWumpus when the turn ends.
+func (fv *FieldView) startWumpus() {
 
-	fv := mg.Fieldview
+	mg := fv.mule
+	wh := new(wumpusHunt)
+	fv.wumpus = wh
 
 	// Get the indices of the plots with mountains
-	var xv, yv []int
-	for i := 0; i < nrow; i++ {
-		for j := 0; j < ncol; j++ {
+	for i := 0; i < mg.Model.nrow; i++ {
+		for j := 0; j < mg.Model.ncol; j++ {
 			pl := mg.Model.GetPlot(i, j)
 			if pl.Mountains > 0 {
-				yv = append(yv, i)
-				xv = append(xv, j)
+				wh.yv = append(wh.yv, i)
+				wh.xv = append(wh.xv, j)
 			}
 		}
 	}
+	if len(wh.xv) == 0 {
+		// Nowhere for the wumpus to hide
+		return
+	}
 
-	for {
-		// wait
-		x := 2 + int(rand.Int63()%int64(10))
-		time.Sleep(time.Duration(x) * time.Second)
+	rl := mg.Model.rules
+	wh.rng = rand.New(rand.NewSource(mg.Model.rng.Int63()))
+	wh.timer = mg.Clock.NewTimer(randSeconds(wh.rng, rl.WumpusHideMin, rl.WumpusHideMax))
+}
+
+// stopWumpus ends the hunt started by startWumpus.
+func (fv *FieldView) stopWumpus() {
+	if wh := fv.wumpus; wh != nil {
+		if wh.timer != nil {
+			wh.timer.Stop()
+		}
+		fv.wumpus = nil
+	}
+}
 
-		// random offset within the plot
-		k := int(rand.Int63() % int64(len(xv)))
-		i0 := int(rand.Int63() % int64(ploth-1))
-		j0 := int(rand.Int63() % int64(plotw-1))
+// C returns the channel of the hunt's timer, nil, and so never ready,
+// if there is no wumpus.
+func (wh *wumpusHunt) C() <-chan time.Time {
+	if wh == nil || wh.timer == nil {
+		return nil
+	}
+	return wh.timer.C()
+}
 
-		fv.wumpusy = yv[k]*ploth + i0 + 1
-		fv.wumpusx = xv[k]*plotw + j0 + 1
-		fv.wumpusOut = true
+// moveWumpus is called from the turn's event loop when the wumpus'
+// timer goes off, to show it somewhere in the mountains or hide it
+// again.
+func (fv *FieldView) moveWumpus(v *view) {
 
-		mg.wumpusStatus <- wumpusInfo{fv.wumpusx, fv.wumpusy, true}
+	mg := fv.mule
+	rl := mg.Model.rules
+	wh := fv.wumpus
 
-		// wait
-		x = 5 + int(rand.Int63()%int64(20))
-		time.Sleep(time.Duration(x) * time.Second)
-		fv.wumpusOut = false
-		mg.wumpusStatus <- wumpusInfo{fv.wumpusx, fv.wumpusy, false}
+	if wh.showing {
+		if wh.out {
+			v.RestorePoint(wh.x, wh.y)
+		}
+		wh.showing, wh.out = false, false
+		wh.timer = mg.Clock.NewTimer(randSeconds(wh.rng, rl.WumpusHideMin, rl.WumpusHideMax))
+		mg.Renderer.Flush()
+		return
 	}
+
+	// random offset within the plot
+	k := int(wh.rng.Int63() % int64(len(wh.xv)))
+	i0 := int(wh.rng.Int63() % int64(mg.ploth-1))
+	j0 := int(wh.rng.Int63() % int64(mg.plotw-1))
+
+	wh.showing, wh.out = true, true
+	wh.x = wh.xv[k]*mg.plotw + j0 + 1
+	wh.y = wh.yv[k]*mg.ploth + i0 + 1
+	v.Print(wh.x, wh.y, "W", ColorWhite, ColorBlack, false, false)
+	wh.timer = mg.Clock.NewTimer(randSeconds(wh.rng, rl.WumpusShowMin, rl.WumpusShowMax))
+	mg.Renderer.Flush()
+}
+
+// wumpusReward returns the money for catching the wumpus in round r.
+func (rl *Rules) wumpusReward(r int) int {
+	return rl.WumpusReward + r*rl.WumpusRewardStep
+}
+
+// randSeconds returns a random whole number of seconds from lo to hi.
+func randSeconds(rng *rand.Rand, lo, hi int) time.Duration {
+	return time.Duration(lo+rng.Intn(hi-lo+1)) * time.Second
 }